@@ -0,0 +1,190 @@
+package ginga
+
+import (
+	"encoding/binary"
+)
+
+// --- Processamento de vários blocos ---
+
+// lanes é o número de blocos processados em paralelo: as palavras de quatro
+// estados ficam intercaladas em registradores, e cada operação ARX é aplicada
+// às quatro faixas em sequência, sem dependência entre elas.
+const lanes = 4
+
+// schedule calcula as subchaves de todas as rodadas.
+func schedule(key []byte) (sk [Rounds][4]uint32) {
+	var k [8]uint32
+	for i := 0; i < 8; i++ {
+		k[i] = binary.LittleEndian.Uint32(key[i*4 : (i+1)*4])
+	}
+	for r := 0; r < Rounds; r++ {
+		for i := 0; i < 4; i++ {
+			sk[r][i] = subKey32(&k, r, i)
+		}
+	}
+	return sk
+}
+
+// mix é mixState32 sobre palavras soltas, para manter os estados em
+// registradores.
+func mix(x0, x1, x2, x3 uint32) (uint32, uint32, uint32, uint32) {
+	x0 ^= rotl32(x1, 5)
+	x1 ^= rotl32(x2, 11)
+	x2 ^= rotl32(x3, 17)
+	x3 ^= rotl32(x0, 23)
+	return x0, x1, x2, x3
+}
+
+func invMix(x0, x1, x2, x3 uint32) (uint32, uint32, uint32, uint32) {
+	x3 ^= rotl32(x0, 23)
+	x2 ^= rotl32(x3, 17)
+	x1 ^= rotl32(x2, 11)
+	x0 ^= rotl32(x1, 5)
+	return x0, x1, x2, x3
+}
+
+// encrypt4 cifra quatro blocos consecutivos de src; xij é a palavra i do
+// bloco j.
+func encrypt4(dst, src []byte, sk *[Rounds][4]uint32) {
+	_, _ = src[63], dst[63]
+	x00, x10, x20, x30 := binary.LittleEndian.Uint32(src[0:]), binary.LittleEndian.Uint32(src[4:]), binary.LittleEndian.Uint32(src[8:]), binary.LittleEndian.Uint32(src[12:])
+	x01, x11, x21, x31 := binary.LittleEndian.Uint32(src[16:]), binary.LittleEndian.Uint32(src[20:]), binary.LittleEndian.Uint32(src[24:]), binary.LittleEndian.Uint32(src[28:])
+	x02, x12, x22, x32 := binary.LittleEndian.Uint32(src[32:]), binary.LittleEndian.Uint32(src[36:]), binary.LittleEndian.Uint32(src[40:]), binary.LittleEndian.Uint32(src[44:])
+	x03, x13, x23, x33 := binary.LittleEndian.Uint32(src[48:]), binary.LittleEndian.Uint32(src[52:]), binary.LittleEndian.Uint32(src[56:]), binary.LittleEndian.Uint32(src[60:])
+	for r := 0; r < Rounds; r++ {
+		k := &sk[r]
+		x00, x01, x02, x03 = round32(x00, k[0], r), round32(x01, k[0], r), round32(x02, k[0], r), round32(x03, k[0], r)
+		x10, x11, x12, x13 = round32(x10, k[1], r), round32(x11, k[1], r), round32(x12, k[1], r), round32(x13, k[1], r)
+		x20, x21, x22, x23 = round32(x20, k[2], r), round32(x21, k[2], r), round32(x22, k[2], r), round32(x23, k[2], r)
+		x30, x31, x32, x33 = round32(x30, k[3], r), round32(x31, k[3], r), round32(x32, k[3], r), round32(x33, k[3], r)
+		x00, x10, x20, x30 = mix(x00, x10, x20, x30)
+		x01, x11, x21, x31 = mix(x01, x11, x21, x31)
+		x02, x12, x22, x32 = mix(x02, x12, x22, x32)
+		x03, x13, x23, x33 = mix(x03, x13, x23, x33)
+	}
+	binary.LittleEndian.PutUint32(dst[0:], x00)
+	binary.LittleEndian.PutUint32(dst[4:], x10)
+	binary.LittleEndian.PutUint32(dst[8:], x20)
+	binary.LittleEndian.PutUint32(dst[12:], x30)
+	binary.LittleEndian.PutUint32(dst[16:], x01)
+	binary.LittleEndian.PutUint32(dst[20:], x11)
+	binary.LittleEndian.PutUint32(dst[24:], x21)
+	binary.LittleEndian.PutUint32(dst[28:], x31)
+	binary.LittleEndian.PutUint32(dst[32:], x02)
+	binary.LittleEndian.PutUint32(dst[36:], x12)
+	binary.LittleEndian.PutUint32(dst[40:], x22)
+	binary.LittleEndian.PutUint32(dst[44:], x32)
+	binary.LittleEndian.PutUint32(dst[48:], x03)
+	binary.LittleEndian.PutUint32(dst[52:], x13)
+	binary.LittleEndian.PutUint32(dst[56:], x23)
+	binary.LittleEndian.PutUint32(dst[60:], x33)
+}
+
+func decrypt4(dst, src []byte, sk *[Rounds][4]uint32) {
+	_, _ = src[63], dst[63]
+	x00, x10, x20, x30 := binary.LittleEndian.Uint32(src[0:]), binary.LittleEndian.Uint32(src[4:]), binary.LittleEndian.Uint32(src[8:]), binary.LittleEndian.Uint32(src[12:])
+	x01, x11, x21, x31 := binary.LittleEndian.Uint32(src[16:]), binary.LittleEndian.Uint32(src[20:]), binary.LittleEndian.Uint32(src[24:]), binary.LittleEndian.Uint32(src[28:])
+	x02, x12, x22, x32 := binary.LittleEndian.Uint32(src[32:]), binary.LittleEndian.Uint32(src[36:]), binary.LittleEndian.Uint32(src[40:]), binary.LittleEndian.Uint32(src[44:])
+	x03, x13, x23, x33 := binary.LittleEndian.Uint32(src[48:]), binary.LittleEndian.Uint32(src[52:]), binary.LittleEndian.Uint32(src[56:]), binary.LittleEndian.Uint32(src[60:])
+	for r := Rounds - 1; r >= 0; r-- {
+		k := &sk[r]
+		x00, x10, x20, x30 = invMix(x00, x10, x20, x30)
+		x01, x11, x21, x31 = invMix(x01, x11, x21, x31)
+		x02, x12, x22, x32 = invMix(x02, x12, x22, x32)
+		x03, x13, x23, x33 = invMix(x03, x13, x23, x33)
+		x00, x01, x02, x03 = invRound32(x00, k[0], r), invRound32(x01, k[0], r), invRound32(x02, k[0], r), invRound32(x03, k[0], r)
+		x10, x11, x12, x13 = invRound32(x10, k[1], r), invRound32(x11, k[1], r), invRound32(x12, k[1], r), invRound32(x13, k[1], r)
+		x20, x21, x22, x23 = invRound32(x20, k[2], r), invRound32(x21, k[2], r), invRound32(x22, k[2], r), invRound32(x23, k[2], r)
+		x30, x31, x32, x33 = invRound32(x30, k[3], r), invRound32(x31, k[3], r), invRound32(x32, k[3], r), invRound32(x33, k[3], r)
+	}
+	binary.LittleEndian.PutUint32(dst[0:], x00)
+	binary.LittleEndian.PutUint32(dst[4:], x10)
+	binary.LittleEndian.PutUint32(dst[8:], x20)
+	binary.LittleEndian.PutUint32(dst[12:], x30)
+	binary.LittleEndian.PutUint32(dst[16:], x01)
+	binary.LittleEndian.PutUint32(dst[20:], x11)
+	binary.LittleEndian.PutUint32(dst[24:], x21)
+	binary.LittleEndian.PutUint32(dst[28:], x31)
+	binary.LittleEndian.PutUint32(dst[32:], x02)
+	binary.LittleEndian.PutUint32(dst[36:], x12)
+	binary.LittleEndian.PutUint32(dst[40:], x22)
+	binary.LittleEndian.PutUint32(dst[44:], x32)
+	binary.LittleEndian.PutUint32(dst[48:], x03)
+	binary.LittleEndian.PutUint32(dst[52:], x13)
+	binary.LittleEndian.PutUint32(dst[56:], x23)
+	binary.LittleEndian.PutUint32(dst[60:], x33)
+}
+
+// encrypt1 cifra um único bloco com as subchaves pré-calculadas.
+func encrypt1(dst, src []byte, sk *[Rounds][4]uint32) {
+	var c [4]uint32
+	for i := 0; i < 4; i++ {
+		c[i] = binary.LittleEndian.Uint32(src[4*i:])
+	}
+	for r := 0; r < Rounds; r++ {
+		for i := 0; i < 4; i++ {
+			c[i] = round32(c[i], sk[r][i], r)
+		}
+		mixState32(&c)
+	}
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint32(dst[4*i:], c[i])
+	}
+}
+
+func decrypt1(dst, src []byte, sk *[Rounds][4]uint32) {
+	var p [4]uint32
+	for i := 0; i < 4; i++ {
+		p[i] = binary.LittleEndian.Uint32(src[4*i:])
+	}
+	for r := Rounds - 1; r >= 0; r-- {
+		invMixState32(&p)
+		for i := 0; i < 4; i++ {
+			p[i] = invRound32(p[i], sk[r][i], r)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint32(dst[4*i:], p[i])
+	}
+}
+
+// EncryptBlocks cifra len(src)/16 blocos independentes (ECB), quatro por vez,
+// ou com as rotinas em assembly da arquitetura quando disponíveis.
+// src deve ter um número inteiro de blocos; dst pode ser igual a src.
+func (c *gingaCipher) EncryptBlocks(dst, src []byte) {
+	if len(src)%BlockSize != 0 || len(dst) < len(src) {
+		panic("ginga: input not full blocks")
+	}
+	n := encryptBlocksArch(dst, src, &c.sk)
+	src, dst = src[n:], dst[n:]
+	for len(src) >= lanes*BlockSize {
+		encrypt4(dst, src, &c.sk)
+		src, dst = src[lanes*BlockSize:], dst[lanes*BlockSize:]
+	}
+	for ; len(src) > 0; src, dst = src[BlockSize:], dst[BlockSize:] {
+		encrypt1(dst, src, &c.sk)
+	}
+}
+
+// DecryptBlocks decifra len(src)/16 blocos independentes, quatro por vez.
+func (c *gingaCipher) DecryptBlocks(dst, src []byte) {
+	if len(src)%BlockSize != 0 || len(dst) < len(src) {
+		panic("ginga: input not full blocks")
+	}
+	n := decryptBlocksArch(dst, src, &c.sk)
+	src, dst = src[n:], dst[n:]
+	for len(src) >= lanes*BlockSize {
+		decrypt4(dst, src, &c.sk)
+		src, dst = src[lanes*BlockSize:], dst[lanes*BlockSize:]
+	}
+	for ; len(src) > 0; src, dst = src[BlockSize:], dst[BlockSize:] {
+		decrypt1(dst, src, &c.sk)
+	}
+}
+
+// multiBlock é implementado pelas cifras que processam vários blocos por
+// chamada; os modos de operação usam esse caminho quando disponível.
+type multiBlock interface {
+	EncryptBlocks(dst, src []byte)
+	DecryptBlocks(dst, src []byte)
+}
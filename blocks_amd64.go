@@ -0,0 +1,65 @@
+//go:build amd64 && !purego
+
+package ginga
+
+// useAVX2 indica se a CPU e o sistema operacional suportam AVX2 (estado YMM
+// habilitado em XCR0).
+var useAVX2 = func() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	if ecx1&(1<<27) == 0 || ecx1&(1<<28) == 0 { // OSXSAVE, AVX
+		return false
+	}
+	if xcr0, _ := xgetbv(); xcr0&6 != 6 {
+		return false
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	return ebx7&(1<<5) != 0
+}()
+
+//go:noescape
+func encrypt4SSE2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+
+//go:noescape
+func decrypt4SSE2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+
+//go:noescape
+func encrypt8AVX2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+
+//go:noescape
+func decrypt8AVX2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
+
+// encryptBlocksArch cifra o maior prefixo de src que cabe em grupos de oito
+// (AVX2) e quatro (SSE2) blocos e devolve quantos bytes processou.
+func encryptBlocksArch(dst, src []byte, sk *[Rounds][4]uint32) int {
+	n := 0
+	if g := len(src) / (8 * BlockSize); useAVX2 && g > 0 {
+		encrypt8AVX2(&dst[0], &src[0], g, sk)
+		n = g * 8 * BlockSize
+	}
+	if g := (len(src) - n) / (4 * BlockSize); g > 0 {
+		encrypt4SSE2(&dst[n], &src[n], g, sk)
+		n += g * 4 * BlockSize
+	}
+	return n
+}
+
+func decryptBlocksArch(dst, src []byte, sk *[Rounds][4]uint32) int {
+	n := 0
+	if g := len(src) / (8 * BlockSize); useAVX2 && g > 0 {
+		decrypt8AVX2(&dst[0], &src[0], g, sk)
+		n = g * 8 * BlockSize
+	}
+	if g := (len(src) - n) / (4 * BlockSize); g > 0 {
+		decrypt4SSE2(&dst[n], &src[n], g, sk)
+		n += g * 4 * BlockSize
+	}
+	return n
+}
@@ -0,0 +1,371 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// Quatro (SSE2) ou oito (AVX2) blocos por vez: depois da transposição, o
+// registrador i guarda a palavra i de todos os blocos, e cada rodada aplica
+// a mesma sequência ARX às faixas. As rotações de confuse32 e de round32 são
+// consecutivas e viram uma só, ROTL(r+10) seguida de ROTL(r+5).
+
+// ROTL gira as faixas de 32 bits de x por n bits, usando t como temporário.
+#define ROTL(n, x, t) \
+	MOVO  x, t;          \
+	PSLLL $((n)), x;       \
+	PSRLL $(32-(n)), t;    \
+	POR   t, x
+
+// ROUND aplica round32 às faixas de x com a subchave em k.
+#define ROUND(x, k, t, n1, n2) \
+	PADDL k, x;      \
+	PXOR  X7, x;     \
+	PADDL X8, x;     \
+	ROTL(n1, x, t);  \
+	PXOR  k, x;      \
+	ROTL(n2, x, t)
+
+// INVROUND desfaz ROUND.
+#define INVROUND(x, k, t, n1, n2) \
+	ROTL(32-n2, x, t); \
+	PXOR  k, x;        \
+	ROTL(32-n1, x, t); \
+	PSUBL X8, x;       \
+	PXOR  X7, x;       \
+	PSUBL k, x
+
+// MIX aplica a ^= ROTL(b, n).
+#define MIX(a, b, n) \
+	MOVO b, X5;     \
+	ROTL(n, X5, X6); \
+	PXOR X5, a
+
+// ENC cifra uma rodada; off é o deslocamento de sk[r] a partir de SI.
+#define ENC(off, n1, n2) \
+	MOVOU off(SI), X9;             \
+	PSHUFD $0x00, X9, X4;          \
+	ROUND(X0, X4, X5, n1, n2);     \
+	PSHUFD $0x55, X9, X4;          \
+	ROUND(X1, X4, X5, n1, n2);     \
+	PSHUFD $0xaa, X9, X4;          \
+	ROUND(X2, X4, X5, n1, n2);     \
+	PSHUFD $0xff, X9, X4;          \
+	ROUND(X3, X4, X5, n1, n2);     \
+	MIX(X0, X1, 5);                \
+	MIX(X1, X2, 11);               \
+	MIX(X2, X3, 17);               \
+	MIX(X3, X0, 23)
+
+#define DEC(off, n1, n2) \
+	MIX(X3, X0, 23);               \
+	MIX(X2, X3, 17);               \
+	MIX(X1, X2, 11);               \
+	MIX(X0, X1, 5);                \
+	MOVOU off(SI), X9;             \
+	PSHUFD $0x00, X9, X4;          \
+	INVROUND(X0, X4, X5, n1, n2);  \
+	PSHUFD $0x55, X9, X4;          \
+	INVROUND(X1, X4, X5, n1, n2);  \
+	PSHUFD $0xaa, X9, X4;          \
+	INVROUND(X2, X4, X5, n1, n2);  \
+	PSHUFD $0xff, X9, X4;          \
+	INVROUND(X3, X4, X5, n1, n2)
+
+// TRANSPOSE troca linhas (blocos) por colunas (palavras) em X0..X3; é a
+// própria inversa.
+#define TRANSPOSE \
+	MOVO       X0, X4;  \
+	PUNPCKLLQ  X1, X4;  \
+	PUNPCKHLQ  X1, X0;  \
+	MOVO       X2, X5;  \
+	PUNPCKLLQ  X3, X5;  \
+	PUNPCKHLQ  X3, X2;  \
+	MOVO       X4, X1;  \
+	PUNPCKLQDQ X5, X4;  \
+	PUNPCKHQDQ X5, X1;  \
+	MOVO       X0, X3;  \
+	PUNPCKLQDQ X2, X0;  \
+	PUNPCKHQDQ X2, X3;  \
+	MOVO       X0, X2;  \
+	MOVO       X4, X0
+
+// CONSTS carrega 0xA5A5A5A5 em X7 e 0x3C3C3C3C em X8.
+#define CONSTS \
+	MOVL   $0xA5A5A5A5, AX; \
+	MOVL   AX, X7;          \
+	PSHUFD $0, X7, X7;      \
+	MOVL   $0x3C3C3C3C, AX; \
+	MOVL   AX, X8;          \
+	PSHUFD $0, X8, X8
+
+// func encrypt4SSE2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+TEXT ·encrypt4SSE2(SB), NOSPLIT, $0-32
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), DX
+	MOVQ n+16(FP), CX
+	MOVQ sk+24(FP), SI
+	CONSTS
+
+loop:
+	MOVOU 0(DX), X0
+	MOVOU 16(DX), X1
+	MOVOU 32(DX), X2
+	MOVOU 48(DX), X3
+	TRANSPOSE
+	ENC(0, 10, 5)
+	ENC(16, 11, 6)
+	ENC(32, 12, 7)
+	ENC(48, 13, 8)
+	ENC(64, 14, 9)
+	ENC(80, 15, 10)
+	ENC(96, 16, 11)
+	ENC(112, 17, 12)
+	ENC(128, 18, 13)
+	ENC(144, 19, 14)
+	ENC(160, 20, 15)
+	ENC(176, 21, 16)
+	ENC(192, 22, 17)
+	ENC(208, 23, 18)
+	ENC(224, 24, 19)
+	ENC(240, 25, 20)
+	TRANSPOSE
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	ADDQ  $64, DX
+	ADDQ  $64, DI
+	DECQ  CX
+	JNZ   loop
+	RET
+
+// func decrypt4SSE2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+TEXT ·decrypt4SSE2(SB), NOSPLIT, $0-32
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), DX
+	MOVQ n+16(FP), CX
+	MOVQ sk+24(FP), SI
+	CONSTS
+
+loop:
+	MOVOU 0(DX), X0
+	MOVOU 16(DX), X1
+	MOVOU 32(DX), X2
+	MOVOU 48(DX), X3
+	TRANSPOSE
+	DEC(240, 25, 20)
+	DEC(224, 24, 19)
+	DEC(208, 23, 18)
+	DEC(192, 22, 17)
+	DEC(176, 21, 16)
+	DEC(160, 20, 15)
+	DEC(144, 19, 14)
+	DEC(128, 18, 13)
+	DEC(112, 17, 12)
+	DEC(96, 16, 11)
+	DEC(80, 15, 10)
+	DEC(64, 14, 9)
+	DEC(48, 13, 8)
+	DEC(32, 12, 7)
+	DEC(16, 11, 6)
+	DEC(0, 10, 5)
+	TRANSPOSE
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	ADDQ  $64, DX
+	ADDQ  $64, DI
+	DECQ  CX
+	JNZ   loop
+	RET
+
+// As versões AVX2 usam as mesmas rodadas em registradores Y: a metade baixa
+// guarda os blocos 0..3 e a alta os blocos 4..7 (as instruções de
+// desempacotamento agem em cada metade de 128 bits).
+
+#define VROTL(n, x, t) \
+	VPSLLD $((n)), x, t;     \
+	VPSRLD $(32-(n)), x, x;  \
+	VPOR   t, x, x
+
+#define VROUND(x, k, n1, n2) \
+	VPADDD k, x, x;    \
+	VPXOR  Y7, x, x;   \
+	VPADDD Y8, x, x;   \
+	VROTL(n1, x, Y5);  \
+	VPXOR  k, x, x;    \
+	VROTL(n2, x, Y5)
+
+#define VINVROUND(x, k, n1, n2) \
+	VROTL(32-n2, x, Y5); \
+	VPXOR  k, x, x;      \
+	VROTL(32-n1, x, Y5); \
+	VPSUBD Y8, x, x;     \
+	VPXOR  Y7, x, x;     \
+	VPSUBD k, x, x
+
+#define VMIX(a, b, n) \
+	VPSLLD $((n)), b, Y5;    \
+	VPSRLD $(32-(n)), b, Y6; \
+	VPXOR  Y5, a, a;       \
+	VPXOR  Y6, a, a
+
+#define VENC(off, n1, n2) \
+	VPBROADCASTD (off+0)(SI), Y4;  \
+	VROUND(Y0, Y4, n1, n2);        \
+	VPBROADCASTD (off+4)(SI), Y4;  \
+	VROUND(Y1, Y4, n1, n2);        \
+	VPBROADCASTD (off+8)(SI), Y4;  \
+	VROUND(Y2, Y4, n1, n2);        \
+	VPBROADCASTD (off+12)(SI), Y4; \
+	VROUND(Y3, Y4, n1, n2);        \
+	VMIX(Y0, Y1, 5);               \
+	VMIX(Y1, Y2, 11);              \
+	VMIX(Y2, Y3, 17);              \
+	VMIX(Y3, Y0, 23)
+
+#define VDEC(off, n1, n2) \
+	VMIX(Y3, Y0, 23);              \
+	VMIX(Y2, Y3, 17);              \
+	VMIX(Y1, Y2, 11);              \
+	VMIX(Y0, Y1, 5);               \
+	VPBROADCASTD (off+0)(SI), Y4;  \
+	VINVROUND(Y0, Y4, n1, n2);     \
+	VPBROADCASTD (off+4)(SI), Y4;  \
+	VINVROUND(Y1, Y4, n1, n2);     \
+	VPBROADCASTD (off+8)(SI), Y4;  \
+	VINVROUND(Y2, Y4, n1, n2);     \
+	VPBROADCASTD (off+12)(SI), Y4; \
+	VINVROUND(Y3, Y4, n1, n2)
+
+#define VTRANSPOSE \
+	VPUNPCKLDQ  Y1, Y0, Y4; \
+	VPUNPCKHDQ  Y1, Y0, Y0; \
+	VPUNPCKLDQ  Y3, Y2, Y5; \
+	VPUNPCKHDQ  Y3, Y2, Y2; \
+	VPUNPCKLQDQ Y5, Y4, Y1; \
+	VPUNPCKHQDQ Y5, Y4, Y6; \
+	VPUNPCKLQDQ Y2, Y0, Y4; \
+	VPUNPCKHQDQ Y2, Y0, Y3; \
+	VMOVDQA     Y1, Y0;     \
+	VMOVDQA     Y6, Y1;     \
+	VMOVDQA     Y4, Y2
+
+// VLOAD lê os blocos j e j+4 nas metades de Yj.
+#define VLOAD \
+	VMOVDQU    0(DX), X0;           \
+	VINSERTI128 $1, 64(DX), Y0, Y0; \
+	VMOVDQU    16(DX), X1;          \
+	VINSERTI128 $1, 80(DX), Y1, Y1; \
+	VMOVDQU    32(DX), X2;          \
+	VINSERTI128 $1, 96(DX), Y2, Y2; \
+	VMOVDQU    48(DX), X3;          \
+	VINSERTI128 $1, 112(DX), Y3, Y3
+
+#define VSTORE \
+	VMOVDQU      X0, 0(DI);         \
+	VEXTRACTI128 $1, Y0, 64(DI);    \
+	VMOVDQU      X1, 16(DI);        \
+	VEXTRACTI128 $1, Y1, 80(DI);    \
+	VMOVDQU      X2, 32(DI);        \
+	VEXTRACTI128 $1, Y2, 96(DI);    \
+	VMOVDQU      X3, 48(DI);        \
+	VEXTRACTI128 $1, Y3, 112(DI)
+
+#define VCONSTS \
+	MOVL         $0xA5A5A5A5, AX; \
+	MOVL         AX, X7;          \
+	VPBROADCASTD X7, Y7;          \
+	MOVL         $0x3C3C3C3C, AX; \
+	MOVL         AX, X8;          \
+	VPBROADCASTD X8, Y8
+
+// func encrypt8AVX2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+TEXT ·encrypt8AVX2(SB), NOSPLIT, $0-32
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), DX
+	MOVQ n+16(FP), CX
+	MOVQ sk+24(FP), SI
+	VCONSTS
+
+loop:
+	VLOAD
+	VTRANSPOSE
+	VENC(0, 10, 5)
+	VENC(16, 11, 6)
+	VENC(32, 12, 7)
+	VENC(48, 13, 8)
+	VENC(64, 14, 9)
+	VENC(80, 15, 10)
+	VENC(96, 16, 11)
+	VENC(112, 17, 12)
+	VENC(128, 18, 13)
+	VENC(144, 19, 14)
+	VENC(160, 20, 15)
+	VENC(176, 21, 16)
+	VENC(192, 22, 17)
+	VENC(208, 23, 18)
+	VENC(224, 24, 19)
+	VENC(240, 25, 20)
+	VTRANSPOSE
+	VSTORE
+	ADDQ $128, DX
+	ADDQ $128, DI
+	DECQ CX
+	JNZ  loop
+	VZEROUPPER
+	RET
+
+// func decrypt8AVX2(dst, src *byte, n int, sk *[Rounds][4]uint32)
+TEXT ·decrypt8AVX2(SB), NOSPLIT, $0-32
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), DX
+	MOVQ n+16(FP), CX
+	MOVQ sk+24(FP), SI
+	VCONSTS
+
+loop:
+	VLOAD
+	VTRANSPOSE
+	VDEC(240, 25, 20)
+	VDEC(224, 24, 19)
+	VDEC(208, 23, 18)
+	VDEC(192, 22, 17)
+	VDEC(176, 21, 16)
+	VDEC(160, 20, 15)
+	VDEC(144, 19, 14)
+	VDEC(128, 18, 13)
+	VDEC(112, 17, 12)
+	VDEC(96, 16, 11)
+	VDEC(80, 15, 10)
+	VDEC(64, 14, 9)
+	VDEC(48, 13, 8)
+	VDEC(32, 12, 7)
+	VDEC(16, 11, 6)
+	VDEC(0, 10, 5)
+	VTRANSPOSE
+	VSTORE
+	ADDQ $128, DX
+	ADDQ $128, DI
+	DECQ CX
+	JNZ  loop
+	VZEROUPPER
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
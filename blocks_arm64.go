@@ -0,0 +1,27 @@
+//go:build arm64 && ginga_neon && !purego
+
+package ginga
+
+//go:noescape
+func encrypt4NEON(dst, src *byte, n int, sk *[Rounds][4]uint32)
+
+//go:noescape
+func decrypt4NEON(dst, src *byte, n int, sk *[Rounds][4]uint32)
+
+// encryptBlocksArch cifra o maior prefixo de src com múltiplo de quatro
+// blocos em NEON e devolve quantos bytes processou.
+func encryptBlocksArch(dst, src []byte, sk *[Rounds][4]uint32) int {
+	g := len(src) / (4 * BlockSize)
+	if g > 0 {
+		encrypt4NEON(&dst[0], &src[0], g, sk)
+	}
+	return g * 4 * BlockSize
+}
+
+func decryptBlocksArch(dst, src []byte, sk *[Rounds][4]uint32) int {
+	g := len(src) / (4 * BlockSize)
+	if g > 0 {
+		decrypt4NEON(&dst[0], &src[0], g, sk)
+	}
+	return g * 4 * BlockSize
+}
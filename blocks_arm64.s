@@ -0,0 +1,142 @@
+//go:build arm64 && ginga_neon && !purego
+
+#include "textflag.h"
+
+// Quatro blocos por vez em NEON. VLD4 já desintercala as palavras: Vi recebe
+// a palavra i dos quatro blocos, e VST4 faz o caminho inverso. As rotações
+// de confuse32 e de round32 são consecutivas e viram ROTL(r+10), ROTL(r+5).
+
+// ROTL gira as faixas de 32 bits de x por n bits, usando t como temporário.
+#define ROTL(n, x, t) \
+	VSHL  $((n)), x.S4, t.S4;     \
+	VUSHR $(32-(n)), x.S4, x.S4;  \
+	VORR  t.B16, x.B16, x.B16
+
+// ROUND aplica round32 às faixas de x com a subchave em V4.
+#define ROUND(x, n1, n2) \
+	VADD V4.S4, x.S4, x.S4;    \
+	VEOR V7.B16, x.B16, x.B16; \
+	VADD V8.S4, x.S4, x.S4;    \
+	ROTL(n1, x, V5);           \
+	VEOR V4.B16, x.B16, x.B16; \
+	ROTL(n2, x, V5)
+
+// INVROUND desfaz ROUND.
+#define INVROUND(x, n1, n2) \
+	ROTL(32-(n2), x, V5);      \
+	VEOR V4.B16, x.B16, x.B16; \
+	ROTL(32-(n1), x, V5);      \
+	VSUB V8.S4, x.S4, x.S4;    \
+	VEOR V7.B16, x.B16, x.B16; \
+	VSUB V4.S4, x.S4, x.S4
+
+// MIX aplica a ^= ROTL(b, n).
+#define MIX(a, b, n) \
+	VSHL  $((n)), b.S4, V5.S4;    \
+	VUSHR $(32-(n)), b.S4, V6.S4; \
+	VEOR  V5.B16, a.B16, a.B16;   \
+	VEOR  V6.B16, a.B16, a.B16
+
+// KEY carrega sk[r] (deslocamento off a partir de R3) em V9.
+#define KEY(off) \
+	ADD  $(off), R3, R2; \
+	VLD1 (R2), [V9.S4]
+
+#define ENC(off, n1, n2) \
+	KEY(off);                \
+	VDUP V9.S[0], V4.S4;     \
+	ROUND(V0, n1, n2);       \
+	VDUP V9.S[1], V4.S4;     \
+	ROUND(V1, n1, n2);       \
+	VDUP V9.S[2], V4.S4;     \
+	ROUND(V2, n1, n2);       \
+	VDUP V9.S[3], V4.S4;     \
+	ROUND(V3, n1, n2);       \
+	MIX(V0, V1, 5);          \
+	MIX(V1, V2, 11);         \
+	MIX(V2, V3, 17);         \
+	MIX(V3, V0, 23)
+
+#define DEC(off, n1, n2) \
+	MIX(V3, V0, 23);         \
+	MIX(V2, V3, 17);         \
+	MIX(V1, V2, 11);         \
+	MIX(V0, V1, 5);          \
+	KEY(off);                \
+	VDUP V9.S[0], V4.S4;     \
+	INVROUND(V0, n1, n2);    \
+	VDUP V9.S[1], V4.S4;     \
+	INVROUND(V1, n1, n2);    \
+	VDUP V9.S[2], V4.S4;     \
+	INVROUND(V2, n1, n2);    \
+	VDUP V9.S[3], V4.S4;     \
+	INVROUND(V3, n1, n2)
+
+// CONSTS carrega 0xA5A5A5A5 em V7 e 0x3C3C3C3C em V8.
+#define CONSTS \
+	MOVW $0xA5A5A5A5, R4; \
+	VDUP R4, V7.S4;       \
+	MOVW $0x3C3C3C3C, R4; \
+	VDUP R4, V8.S4
+
+// func encrypt4NEON(dst, src *byte, n int, sk *[Rounds][4]uint32)
+TEXT ·encrypt4NEON(SB), NOSPLIT, $0-32
+	MOVD dst+0(FP), R0
+	MOVD src+8(FP), R1
+	MOVD n+16(FP), R5
+	MOVD sk+24(FP), R3
+	CONSTS
+
+loop:
+	VLD4.P 64(R1), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ENC(0, 10, 5)
+	ENC(16, 11, 6)
+	ENC(32, 12, 7)
+	ENC(48, 13, 8)
+	ENC(64, 14, 9)
+	ENC(80, 15, 10)
+	ENC(96, 16, 11)
+	ENC(112, 17, 12)
+	ENC(128, 18, 13)
+	ENC(144, 19, 14)
+	ENC(160, 20, 15)
+	ENC(176, 21, 16)
+	ENC(192, 22, 17)
+	ENC(208, 23, 18)
+	ENC(224, 24, 19)
+	ENC(240, 25, 20)
+	VST4.P [V0.S4, V1.S4, V2.S4, V3.S4], 64(R0)
+	SUBS $1, R5, R5
+	BNE  loop
+	RET
+
+// func decrypt4NEON(dst, src *byte, n int, sk *[Rounds][4]uint32)
+TEXT ·decrypt4NEON(SB), NOSPLIT, $0-32
+	MOVD dst+0(FP), R0
+	MOVD src+8(FP), R1
+	MOVD n+16(FP), R5
+	MOVD sk+24(FP), R3
+	CONSTS
+
+loop:
+	VLD4.P 64(R1), [V0.S4, V1.S4, V2.S4, V3.S4]
+	DEC(240, 25, 20)
+	DEC(224, 24, 19)
+	DEC(208, 23, 18)
+	DEC(192, 22, 17)
+	DEC(176, 21, 16)
+	DEC(160, 20, 15)
+	DEC(144, 19, 14)
+	DEC(128, 18, 13)
+	DEC(112, 17, 12)
+	DEC(96, 16, 11)
+	DEC(80, 15, 10)
+	DEC(64, 14, 9)
+	DEC(48, 13, 8)
+	DEC(32, 12, 7)
+	DEC(16, 11, 6)
+	DEC(0, 10, 5)
+	VST4.P [V0.S4, V1.S4, V2.S4, V3.S4], 64(R0)
+	SUBS $1, R5, R5
+	BNE  loop
+	RET
@@ -0,0 +1,9 @@
+//go:build (!amd64 && !(arm64 && ginga_neon)) || purego
+
+package ginga
+
+// Sem assembly, EncryptBlocks e DecryptBlocks usam só o caminho em Go.
+
+func encryptBlocksArch(dst, src []byte, sk *[Rounds][4]uint32) int { return 0 }
+
+func decryptBlocksArch(dst, src []byte, sk *[Rounds][4]uint32) int { return 0 }
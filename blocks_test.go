@@ -0,0 +1,87 @@
+package ginga
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// TestBlocksMatchReference confere EncryptBlocks e DecryptBlocks, que usam
+// o assembly da arquitetura (SSE2/AVX2 em amd64; NEON em arm64 com a tag
+// ginga_neon), contra a cifra de um bloco por vez. Os tamanhos cobrem grupos
+// de quatro e oito blocos com e sem sobra; com -tags purego, confere o
+// caminho em Go.
+func TestBlocksMatchReference(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	b, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := b.(*gingaCipher)
+
+	for n := 0; n <= 40; n++ {
+		src := make([]byte, n*BlockSize)
+		rand.Read(src)
+		want := make([]byte, len(src))
+		for i := 0; i < len(src); i += BlockSize {
+			c.Encrypt(want[i:], src[i:])
+		}
+
+		got := make([]byte, len(src))
+		c.EncryptBlocks(got, src)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("EncryptBlocks com %d blocos difere da cifra bloco a bloco", n)
+		}
+		pt := make([]byte, len(src))
+		c.DecryptBlocks(pt, want)
+		if !bytes.Equal(pt, src) {
+			t.Fatalf("DecryptBlocks com %d blocos difere do texto claro", n)
+		}
+
+		// dst igual a src.
+		inPlace := bytes.Clone(src)
+		c.EncryptBlocks(inPlace, inPlace)
+		if !bytes.Equal(inPlace, want) {
+			t.Fatalf("EncryptBlocks no lugar com %d blocos difere", n)
+		}
+		c.DecryptBlocks(inPlace, inPlace)
+		if !bytes.Equal(inPlace, src) {
+			t.Fatalf("DecryptBlocks no lugar com %d blocos difere", n)
+		}
+	}
+}
+
+// TestBlocksArchMatchGo confere só as rotinas da arquitetura contra
+// encrypt4/decrypt4, no prefixo que elas processam.
+func TestBlocksArchMatchGo(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	sk := schedule(key)
+
+	src := make([]byte, 64*BlockSize)
+	rand.Read(src)
+	got := make([]byte, len(src))
+	n := encryptBlocksArch(got, src, &sk)
+	if n%(lanes*BlockSize) != 0 || n > len(src) {
+		t.Fatalf("encryptBlocksArch processou %d bytes", n)
+	}
+	want := make([]byte, n)
+	for i := 0; i < n; i += lanes * BlockSize {
+		encrypt4(want[i:], src[i:], &sk)
+	}
+	if !bytes.Equal(got[:n], want) {
+		t.Fatal("encryptBlocksArch difere de encrypt4")
+	}
+
+	if m := decryptBlocksArch(got, want, &sk); m != n {
+		t.Fatalf("decryptBlocksArch processou %d bytes; esperado %d", m, n)
+	}
+	pt := make([]byte, n)
+	for i := 0; i < n; i += lanes * BlockSize {
+		decrypt4(pt[i:], want[i:], &sk)
+	}
+	if !bytes.Equal(got[:n], pt) || !bytes.Equal(pt, src[:n]) {
+		t.Fatal("decryptBlocksArch difere de decrypt4")
+	}
+}
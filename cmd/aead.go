@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ AEAD (OCB3 / EAX) ============
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// seq devolve 00 01 02 ... com n bytes.
+func seq(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// aeadVector é um vetor de resposta conhecida; ad e pt são seq(adLen) e
+// seq(ptLen).
+type aeadVector struct {
+	nonce        string
+	adLen, ptLen int
+	out          string
+}
+
+// Vetores da RFC 7253, apêndice A (AES-128, chave 000102...0F).
+var ocbAESVectors = []aeadVector{
+	{"BBAA99887766554433221100", 0, 0, "785407BFFFC8AD9EDCC5520AC9111EE6"},
+	{"BBAA99887766554433221101", 8, 8, "6820B3657B6F615A5725BDA0D3B4EB3A257C9AF1F8F03009"},
+	{"BBAA99887766554433221102", 8, 0, "81017F8203F081277152FADE694A0A00"},
+	{"BBAA99887766554433221103", 0, 8, "45DD69F8F5AAE72414054CD1F35D82760B2CD00D2F99BFA9"},
+	{"BBAA99887766554433221104", 16, 16, "571D535B60B277188BE5147170A9A22C3AD7A4FF3835B8C5701C1CCEC8FC3358"},
+}
+
+// Vetores do artigo do EAX (AES-128): mensagem, chave, nonce, cabeçalho e
+// saída.
+var eaxAESVectors = [][5]string{
+	{"", "233952DEE4D5ED5F9B9C6D6FF80FF478", "62EC67F9C3A4A407FCB2A8C49031A8B3", "6BFB914FD07EAE6B", "E037830E8389F27B025A2D6527E79D01"},
+	{"F7FB", "91945D3F4DCBEE0BF45EF52255F095A4", "BECAF043B0A23D843194BA972C66DEBD", "FA3BFD4806EB53FA", "19DD5C4C9331049D0BDAB0277408F67967E5"},
+	{"1A47CB4933", "01F74AD64077F2E704C0F60ADA3DD523", "70C3DB4F0D26368400A10ED05D2BFF5E", "234A3463C1264AC6", "D851D5BAE03A59F238A23E39199DC9266626C40F80"},
+}
+
+// Vetores Ginga (chave 000102...1F, nonce BBAA99887766554433221100, tag de
+// 16 bytes): saídas de OCB3 e EAX.
+var gingaAEADVectors = []struct {
+	adLen, ptLen int
+	ocb, eax     string
+}{
+	{0, 0, "688723043bbb0cd575bd60cae5bcb733", "11dc891c5556de1e3130c6f20b18818b"},
+	{8, 8, "4ace71eac169ff97ca5bff36578e3bdc19b9281e1c808cc2", "78f3f38718242a1682d0054267e92a2ffb4f5703fc5ca25b"},
+	{0, 40, "56cd1ebaff616dbf29d47e58de73b7da8a5bb06267854145a55428f6507d857e52f9b7185ea0fda5890a8844c5a2f576e83cc5a0f4c28dfe", "78f3f38718242a168fd22d6c2aeaadb5a52fb1780a79d9497c92013490dd93d3469ce56f3cbd3c8f76a9a02197e197d097ebdebb6ff377d7"},
+	{40, 0, "54317c8e9c1842a166f4f525ea3401c2", "2eaf498fdb623aebf2584700df368aaf"},
+	{24, 70, "56cd1ebaff616dbf29d47e58de73b7da8a5bb06267854145a55428f6507d857ed59de3a9a6eef95be3f20b3b3657e1b587eb04f16eceb2779d33ed57db7b5113f370f11bf3599240b5c6d4a19f9eaafdca1d839ffc26", "78f3f38718242a168fd22d6c2aeaadb5a52fb1780a79d9497c92013490dd93d3469ce56f3cbd3c8f4af13bf88997c0d84432a34090c6ddcf07af40eb1911ae0609e442da311a522f9451b80cb4036b527b8cf7a32975"},
+}
+
+// checkVector confere Seal e Open de um vetor.
+func checkVector(name string, a cipher.AEAD, nonce, ad, pt, want []byte) bool {
+	got := a.Seal(nil, nonce, pt, ad)
+	if !bytes.Equal(got, want) {
+		fmt.Printf("❌ %s: esperado %x, obtido %x\n", name, want, got)
+		return false
+	}
+	if p, err := a.Open(nil, nonce, got, ad); err != nil || !bytes.Equal(p, pt) {
+		fmt.Printf("❌ %s: Open não recupera o texto claro\n", name)
+		return false
+	}
+	return true
+}
+
+// ocbIterative é o teste iterativo da RFC 7253 (apêndice A) para TAGLEN
+// = 8·tagSize bits.
+func ocbIterative(tagSize int) []byte {
+	key := make([]byte, 16)
+	key[15] = byte(8 * tagSize)
+	b, _ := aes.NewCipher(key)
+	o, _ := ginga.NewOCB(b, 12, tagSize)
+	nonce := func(x int) []byte { return []byte{10: byte(x >> 8), 11: byte(x)} }
+	var c []byte
+	for i := 0; i < 128; i++ {
+		s := make([]byte, i)
+		c = o.Seal(c, nonce(3*i+1), s, s)
+		c = o.Seal(c, nonce(3*i+2), s, nil)
+		c = o.Seal(c, nonce(3*i+3), nil, s)
+	}
+	return o.Seal(nil, nonce(385), nil, c)
+}
+
+// panics informa se f entra em pânico.
+func panics(f func()) (p bool) {
+	defer func() { p = recover() != nil }()
+	f()
+	return false
+}
+
+// checkAEADEdges confere tags truncadas, adulteração e tamanhos de nonce.
+func checkAEADEdges(name string, newAEAD func(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error),
+	accept, reject []int, prefixTags bool) int {
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ %s: "+format+"\n", append([]any{name}, args...)...)
+		failures++
+	}
+	block, _ := ginga.NewCipher(randomBytes(32))
+	pt, ad := randomBytes(1000), randomBytes(33)
+
+	// Tags de 1 a 16 bytes: tamanho certo, ida e volta, rejeição de bit
+	// trocado e de tag encurtada.
+	nonce := randomBytes(12)
+	full, _ := newAEAD(block, 12, 16)
+	fullCT := full.Seal(nil, nonce, pt, ad)
+	for tagSize := 1; tagSize <= 16; tagSize++ {
+		a, err := newAEAD(block, 12, tagSize)
+		if err != nil {
+			fail("tag de %d bytes recusada: %v", tagSize, err)
+			continue
+		}
+		ct := a.Seal(nil, nonce, pt, ad)
+		if len(ct) != len(pt)+tagSize || a.Overhead() != tagSize {
+			fail("tag de %d bytes com tamanho errado", tagSize)
+		}
+		// EAX trunca a tag completa; OCB3 codifica TAGLEN no nonce e muda a
+		// tag inteira.
+		if prefixTags != bytes.Equal(ct, fullCT[:len(ct)]) && tagSize < 16 {
+			fail("tag de %d bytes: relação com a tag completa inesperada", tagSize)
+		}
+		if p, err := a.Open(ct[:0:0], nonce, ct, ad); err != nil || !bytes.Equal(p, pt) {
+			fail("tag de %d bytes: Open falhou", tagSize)
+		}
+		bad := bytes.Clone(ct)
+		bad[len(bad)-1] ^= 1
+		if _, err := a.Open(nil, nonce, bad, ad); err == nil {
+			fail("tag de %d bytes: tag adulterada aceita", tagSize)
+		}
+		if _, err := a.Open(nil, nonce, ct[:len(ct)-1], ad); err == nil {
+			fail("tag de %d bytes: texto cifrado encurtado aceito", tagSize)
+		}
+		if _, err := a.Open(nil, nonce, ct[:tagSize-1], ad); err == nil {
+			fail("tag de %d bytes: entrada menor que a tag aceita", tagSize)
+		}
+	}
+	for _, tagSize := range []int{0, 17} {
+		if _, err := newAEAD(block, 12, tagSize); err == nil {
+			fail("tag de %d bytes aceita", tagSize)
+		}
+	}
+
+	// Nonces: extremos aceitos, fora da faixa recusados, tamanho errado no
+	// Seal/Open provoca pânico.
+	for _, n := range accept {
+		a, err := newAEAD(block, n, 16)
+		if err != nil {
+			fail("nonce de %d bytes recusado: %v", n, err)
+			continue
+		}
+		nonce := randomBytes(n)
+		// Em ambos os lugares: dst é o próprio buffer de pt.
+		buf := append(bytes.Clone(pt), make([]byte, 16)...)[:len(pt)]
+		ct := a.Seal(buf[:0], nonce, buf, ad)
+		if p, err := a.Open(ct[:0], nonce, ct, ad); err != nil || !bytes.Equal(p, pt) {
+			fail("nonce de %d bytes: ida e volta no mesmo buffer falhou", n)
+		}
+		if !panics(func() { a.Seal(nil, nonce[:n-1], pt, ad) }) {
+			fail("nonce de %d bytes: Seal com nonce curto não entrou em pânico", n)
+		}
+		if !panics(func() { a.Open(nil, append(nonce, 0), ct, ad) }) {
+			fail("nonce de %d bytes: Open com nonce longo não entrou em pânico", n)
+		}
+	}
+	for _, n := range reject {
+		if _, err := newAEAD(block, n, 16); err == nil {
+			fail("nonce de %d bytes aceito", n)
+		}
+	}
+
+	// Nonces que diferem em um bit devem mudar todo o texto cifrado.
+	a, _ := newAEAD(block, 12, 16)
+	n2 := bytes.Clone(nonce)
+	n2[11] ^= 1
+	if c1, c2 := a.Seal(nil, nonce, pt[:16], nil), a.Seal(nil, n2, pt[:16], nil); bytes.Equal(c1[:16], c2[:16]) {
+		fail("nonces distintos geram o mesmo texto cifrado")
+	}
+	return failures
+}
+
+func testAEAD() {
+	fmt.Println("\n🔏 AEAD: OCB3 e EAX")
+	failures := 0
+
+	aesBlock, _ := aes.NewCipher(seq(16))
+	ocbAES, _ := ginga.NewOCB(aesBlock, 12, 16)
+	for i, v := range ocbAESVectors {
+		if !checkVector(fmt.Sprintf("OCB3-AES vetor %d", i+1), ocbAES, mustHex(v.nonce), seq(v.adLen), seq(v.ptLen), mustHex(v.out)) {
+			failures++
+		}
+	}
+	for _, v := range []struct {
+		tagSize int
+		want    string
+	}{{16, "67E944D23256C5E0B6C61FA22FDF1EA2"}, {12, "77A3D8E73589158D25D01209"}, {8, "192C9B7BD90BA06A"}} {
+		if got := ocbIterative(v.tagSize); !bytes.Equal(got, mustHex(v.want)) {
+			fmt.Printf("❌ OCB3-AES iterativo, TAGLEN %d: obtido %x\n", 8*v.tagSize, got)
+			failures++
+		}
+	}
+	for i, v := range eaxAESVectors {
+		b, _ := aes.NewCipher(mustHex(v[1]))
+		e, _ := ginga.NewEAX(b, 16, 16)
+		if !checkVector(fmt.Sprintf("EAX-AES vetor %d", i+1), e, mustHex(v[2]), mustHex(v[3]), mustHex(v[0]), mustHex(v[4])) {
+			failures++
+		}
+	}
+
+	gingaBlock, _ := ginga.NewCipher(seq(32))
+	ocbGinga, _ := ginga.NewOCB(gingaBlock, 12, 16)
+	eaxGinga, _ := ginga.NewEAX(gingaBlock, 12, 16)
+	nonce := mustHex("BBAA99887766554433221100")
+	for i, v := range gingaAEADVectors {
+		if !checkVector(fmt.Sprintf("OCB3-Ginga vetor %d", i+1), ocbGinga, nonce, seq(v.adLen), seq(v.ptLen), mustHex(v.ocb)) {
+			failures++
+		}
+		if !checkVector(fmt.Sprintf("EAX-Ginga vetor %d", i+1), eaxGinga, nonce, seq(v.adLen), seq(v.ptLen), mustHex(v.eax)) {
+			failures++
+		}
+	}
+
+	failures += checkAEADEdges("OCB3", ginga.NewOCB, []int{1, 15}, []int{0, 16}, false)
+	// EAX aceita nonces de qualquer tamanho; 64 bytes cobre vários blocos.
+	failures += checkAEADEdges("EAX", ginga.NewEAX, []int{1, 16, 64}, []int{0}, true)
+
+	if failures == 0 {
+		fmt.Println("✅ Vetores AES (RFC 7253, artigo do EAX) e Ginga conferem; tags truncadas e nonces nos extremos ok")
+	} else {
+		fmt.Printf("❌ %d verificações de AEAD falharam\n", failures)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/pedroalbanese/ginga"
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// ============ DESEMPENHO ============
+
+// gingaHashRef é GingaHash escrito de forma direta, sem as rotinas em
+// assembly do pacote hash, para conferi-las.
+func gingaHashRef(msg []byte) []byte {
+	state := [16]uint32{
+		0x243F6A88, 0x85A308D3, 0x13198A2E, 0x03707344,
+		0xA4093822, 0x299F31D0, 0x082EFA98, 0xEC4E6C89,
+		0x452821E6, 0x38D01377, 0xBE5466CF, 0x34E90C6C,
+		0xC0AC29B7, 0xC97C50DD, 0x3F84D5B5, 0xB5470917,
+	}
+	padded := append(append([]byte(nil), msg...), 0x80)
+	for (len(padded)+8)%gingahash.BlockSize != 0 {
+		padded = append(padded, 0)
+	}
+	padded = binary.LittleEndian.AppendUint64(padded, uint64(len(msg))*8)
+
+	for ; len(padded) > 0; padded = padded[gingahash.BlockSize:] {
+		var m [8]uint32
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint32(padded[4*i:])
+		}
+		prev := state
+		for r := 0; r < 8; r++ {
+			for i := range state {
+				state[i] = gRound(state[i], gSubKey(&m, r, i&7), r)
+			}
+			for i := range state {
+				state[i] ^= gRotl(state[(i+3)&15], (7*i+13)&31)
+			}
+		}
+		for i := range state {
+			state[i] ^= m[i&7] ^ prev[i]
+		}
+	}
+	out := make([]byte, 0, gingahash.DigestSize)
+	for _, w := range state[:8] {
+		out = binary.LittleEndian.AppendUint32(out, w)
+	}
+	return out
+}
+
+// checkFastPaths confere os caminhos de vários blocos (e as rotinas em
+// assembly, quando compiladas) contra as implementações de referência.
+func checkFastPaths() {
+	for n := 0; n <= 2*gingahash.BlockSize+1; n++ {
+		msg := randomBytes(n)
+		h := gingahash.New()
+		h.Write(msg)
+		if !bytes.Equal(h.Sum(nil), gingaHashRef(msg)) {
+			panic("GingaHash diverge da implementação de referência")
+		}
+	}
+
+	key, iv := randomBytes(32), randomBytes(16)
+	block, _ := ginga.NewCipher(key)
+	// 29 blocos: grupos de oito e de quatro e um bloco restante.
+	pt := randomBytes(29 * ginga.BlockSize)
+
+	want := make([]byte, len(pt))
+	for i := 0; i < len(pt); i += ginga.BlockSize {
+		ct, _ := ginga.Encrypt(pt[i:i+ginga.BlockSize], key)
+		copy(want[i:], ct)
+	}
+	got := make([]byte, len(pt))
+	ginga.NewECBEncrypter(block).CryptBlocks(got, pt)
+	if !bytes.Equal(got, want) {
+		panic("EncryptBlocks diverge de ginga.Encrypt")
+	}
+	ginga.NewECBDecrypter(block).CryptBlocks(got, got)
+	if !bytes.Equal(got, pt) {
+		panic("DecryptBlocks não inverte EncryptBlocks")
+	}
+
+	// O contador big-endian de 16 bytes é o mesmo de crypto/cipher.
+	cipher.NewCTR(block, iv).XORKeyStream(want, pt)
+	s := ginga.NewCTR(block, iv)
+	s.XORKeyStream(got[:5], pt[:5])
+	s.XORKeyStream(got[5:], pt[5:])
+	if !bytes.Equal(got, want) {
+		panic("ginga.NewCTR diverge de cipher.NewCTR")
+	}
+	checkCTR(key)
+
+	xts, _ := ginga.NewXTS(randomBytes(64))
+	xts.Encrypt(got, pt, 42)
+	xts.Decrypt(got, got, 42)
+	if !bytes.Equal(got, pt) {
+		panic("XTS.Decrypt não inverte XTS.Encrypt")
+	}
+}
+
+// checkCTR confere ginga.CTR contra ginga_ctr_crypt da porta C (quando
+// compilado com cgo), o Seek e o erro de estouro do contador.
+func checkCTR(key []byte) {
+	block, _ := ginga.NewCipher(key)
+	pt := randomBytes(29*ginga.BlockSize + 7)
+
+	// O vai-um atravessa a metade baixa do contador no terceiro bloco.
+	iv := randomBytes(16)
+	copy(iv[8:], []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFD})
+	ct := make([]byte, len(pt))
+	ginga.NewCTR(block, iv).XORKeyStream(ct, pt)
+	if want := cCTR(key, iv, pt); want != nil && !bytes.Equal(ct, want) {
+		panic("ginga.CTR diverge de ginga_ctr_crypt")
+	}
+
+	s := ginga.NewCTR(block, iv)
+	for _, off := range []int64{37, 0, 16, 200, 5} {
+		if _, err := s.Seek(off, io.SeekStart); err != nil {
+			panic(err)
+		}
+		got := make([]byte, len(pt)-int(off))
+		s.XORKeyStream(got, ct[off:])
+		if !bytes.Equal(got, pt[off:]) {
+			panic(fmt.Sprintf("ginga.CTR.Seek(%d) decifra errado", off))
+		}
+	}
+
+	// Com o contador em FF..FE restam dois blocos: 32 bytes cabem, 33 não.
+	top := bytes.Repeat([]byte{0xFF}, 16)
+	top[15] = 0xFE
+	s = ginga.NewCTR(block, top)
+	if err := s.Crypt(make([]byte, 33), pt[:33]); err != ginga.ErrCounterOverflow {
+		panic("ginga.CTR não acusou o estouro do contador")
+	}
+	if err := s.Crypt(make([]byte, 32), pt[:32]); err != nil {
+		panic(err)
+	}
+	if err := s.Crypt(make([]byte, 1), pt[:1]); err != ginga.ErrCounterOverflow {
+		panic("ginga.CTR deu a volta no contador")
+	}
+	if _, err := s.Seek(32, io.SeekStart); err != ginga.ErrCounterOverflow {
+		panic("ginga.CTR.Seek além do fim do contador")
+	}
+}
+
+// benchThroughput mede f sobre n bytes com testing.Benchmark.
+func benchThroughput(name string, n int, f func(buf []byte)) {
+	buf := randomBytes(n)
+	res := testing.Benchmark(func(b *testing.B) {
+		b.SetBytes(int64(n))
+		for i := 0; i < b.N; i++ {
+			f(buf)
+		}
+	})
+	fmt.Printf("%-32s %10.1f ns/op %9.2f MB/s %6d B/op\n", name,
+		float64(res.NsPerOp()), float64(n)*float64(res.N)/res.T.Seconds()/1e6, res.AllocedBytesPerOp())
+}
+
+func testThroughput() {
+	checkFastPaths()
+	fmt.Println("\n🚀 Vazão (blocos de 4 KiB, exceto o caminho de um bloco):")
+
+	key, iv := randomBytes(32), randomBytes(16)
+	gBlock, _ := ginga.NewCipher(key)
+	aBlock, _ := aes.NewCipher(key)
+	xts, _ := ginga.NewXTS(randomBytes(64))
+	const n = 4096
+
+	benchThroughput("Ginga Encrypt (1 bloco)", ginga.BlockSize, func(buf []byte) {
+		ginga.Encrypt(buf, key)
+	})
+	benchThroughput("Ginga cipher.Block (1 bloco)", ginga.BlockSize, func(buf []byte) {
+		gBlock.Encrypt(buf, buf)
+	})
+	benchThroughput("AES cipher.Block (1 bloco)", ginga.BlockSize, func(buf []byte) {
+		aBlock.Encrypt(buf, buf)
+	})
+	benchThroughput("Ginga ECB por bloco", n, func(buf []byte) {
+		for i := 0; i < len(buf); i += ginga.BlockSize {
+			gBlock.Encrypt(buf[i:], buf[i:])
+		}
+	})
+	benchThroughput("Ginga ECB EncryptBlocks", n, func(buf []byte) {
+		ginga.NewECBEncrypter(gBlock).CryptBlocks(buf, buf)
+	})
+	benchThroughput("Ginga ECB DecryptBlocks", n, func(buf []byte) {
+		ginga.NewECBDecrypter(gBlock).CryptBlocks(buf, buf)
+	})
+	benchThroughput("AES ECB", n, func(buf []byte) {
+		for i := 0; i < len(buf); i += aes.BlockSize {
+			aBlock.Encrypt(buf[i:], buf[i:])
+		}
+	})
+	benchThroughput("Ginga CTR (cipher.NewCTR)", n, func(buf []byte) {
+		cipher.NewCTR(gBlock, iv).XORKeyStream(buf, buf)
+	})
+	benchThroughput("Ginga CTR (ginga.NewCTR)", n, func(buf []byte) {
+		ginga.NewCTR(gBlock, iv).XORKeyStream(buf, buf)
+	})
+	benchThroughput("AES CTR", n, func(buf []byte) {
+		cipher.NewCTR(aBlock, iv).XORKeyStream(buf, buf)
+	})
+	benchThroughput("Ginga XTS", n, func(buf []byte) {
+		xts.Encrypt(buf, buf, 1)
+	})
+	benchThroughput("GingaHash", n, func(buf []byte) {
+		h := gingahash.New()
+		h.Write(buf)
+		h.Sum(nil)
+	})
+}
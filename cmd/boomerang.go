@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ============ BUMERANGUE / SANDUÍCHE ============
+//
+// Ginga reduzida é dividida em E = E1 ∘ Em ∘ E0: E0 tem r0 rodadas a partir
+// da rodada 0, Em é a rodada r0 e E1 as r1 rodadas seguintes. Com trilhas
+// α → β em E0 (probabilidade p) e γ → δ em E1 (probabilidade q), um
+// quarteto volta com ΔP = α com probabilidade ≈ p²·r·q², onde
+// r = BCT(β, γ) / 2^128 é a entrada da tabela de conectividade de Em.
+// As diferenças são de 128 bits, sobre o estado inteiro.
+
+func xorState(a, b [4]uint32) [4]uint32 {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
+
+// BoomerangRounds conta os quartetos que voltam com ΔP = α nas rodadas
+// [from, to), com ΔC = δ e uma chave aleatória por quarteto.
+func BoomerangRounds(from, to int, alpha, delta [4]uint32, samples int) int {
+	hits := 0
+	for n := 0; n < samples; n++ {
+		k := loadKey(randomBytes(32))
+		p1 := loadState(randomBytes(16))
+		p2 := xorState(p1, alpha)
+		c1 := encryptWords(p1, &k, from, to)
+		c2 := encryptWords(p2, &k, from, to)
+		p3 := decryptWords(xorState(c1, delta), &k, from, to)
+		p4 := decryptWords(xorState(c2, delta), &k, from, to)
+		if xorState(p3, p4) == alpha {
+			hits++
+		}
+	}
+	return hits
+}
+
+// ============ BCT ============
+
+// bctWord8 calcula exaustivamente a BCT da parte não linear da palavra,
+// x ↦ ((x + k) ⊕ 0xA5) + 0x3C, reduzida a 8 bits (as rotações de round32
+// só permutam linhas e colunas). bct[β][γ] conta os x com
+// F⁻¹(F(x) ⊕ γ) ⊕ F⁻¹(F(x ⊕ β) ⊕ γ) = β.
+func bctWord8(k uint8) (bct, ddt *[256][256]int) {
+	var f, inv [256]uint8
+	for x := 0; x < 256; x++ {
+		y := ((uint8(x) + k) ^ 0xA5) + 0x3C
+		f[x], inv[y] = y, uint8(x)
+	}
+	bct, ddt = new([256][256]int), new([256][256]int)
+	for b := 0; b < 256; b++ {
+		for x := 0; x < 256; x++ {
+			ddt[b][f[x]^f[x^b]]++
+		}
+		for g := 0; g < 256; g++ {
+			for x := 0; x < 256; x++ {
+				if inv[f[x]^uint8(g)]^inv[f[x^b]^uint8(g)] == uint8(b) {
+					bct[b][g]++
+				}
+			}
+		}
+	}
+	return bct, ddt
+}
+
+// BCTEntry conta os switches bem-sucedidos na rodada r inteira; a fração
+// sobre samples estima BCT(β, γ)/2^128.
+func BCTEntry(r int, beta, gamma [4]uint32, samples int) int {
+	return BoomerangRounds(r, r+1, beta, gamma, samples)
+}
+
+func testBCT(keys int) {
+	fmt.Printf("\n🔌 BCT da palavra não linear reduzida a 8 bits (%d chaves):\n", keys)
+	maxBCT, maxDDT, ladder, violations := 0, 0, 0, 0
+	var sumBCT, sumDDT float64
+	for n := 0; n < keys; n++ {
+		bct, ddt := bctWord8(uint8(randomBytes(1)[0]))
+		for b := 1; b < 256; b++ {
+			for g := 1; g < 256; g++ {
+				maxBCT = max(maxBCT, bct[b][g])
+				maxDDT = max(maxDDT, ddt[b][g])
+				sumBCT += float64(bct[b][g])
+				sumDDT += float64(ddt[b][g])
+				if bct[b][g] == 256 {
+					ladder++
+				}
+				// Para permutações, BCT(β, γ) ≥ DDT(β, γ) vale sempre.
+				if bct[b][g] < ddt[b][g] {
+					violations++
+				}
+			}
+		}
+	}
+	cells := float64(keys * 255 * 255)
+	fmt.Printf("Maior entrada não trivial: BCT %d/256 (DDT %d/256)\n", maxBCT, maxDDT)
+	fmt.Printf("Média não trivial: BCT %.3f, DDT %.3f; entradas BCT = 256 (switch livre): %d\n",
+		sumBCT/cells, sumDDT/cells, ladder)
+	if violations > 0 {
+		fmt.Printf("❌ %d entradas com BCT < DDT: tabela incorreta\n", violations)
+	}
+}
+
+func testBoomerangRounds(maxRounds, samples int, budget time.Duration) {
+	fmt.Printf("\n🪃 Bumerangue sanduíche (Ginga, ΔP/ΔC de 128 bits, %d quartetos):\n", samples)
+
+	// E1 tem no máximo ⌈(maxRounds-1)/2⌉ rodadas e começa em qualquer rodada.
+	trails, _ := trailTable(diffModel, maxRounds/2, budget)
+	for rounds := 3; rounds <= maxRounds; rounds++ {
+		r0 := (rounds - 1) / 2
+		r1 := rounds - 1 - r0
+		e0, e1 := trails[0][r0], trails[r0+1][r1]
+		if e0 == nil || e1 == nil {
+			fmt.Printf("Rodadas %d: trilhas não encontradas no prazo\n", rounds)
+			continue
+		}
+		in, beta := e0.Rounds[0].In, e0.Rounds[r0-1].Out
+		gamma, out := e1.Rounds[0].In, e1.Rounds[r1-1].Out
+
+		bct := BCTEntry(r0, beta, gamma, samples)
+		rw := math.Inf(1)
+		if bct > 0 {
+			rw = -math.Log2(float64(bct) / float64(samples))
+		}
+		predicted := 2*e0.Weight + rw + 2*e1.Weight
+
+		hits := BoomerangRounds(0, rounds, in, out, samples)
+		lo, hi := wilsonInterval(hits, samples, *alpha)
+		fmt.Printf("Rodadas %d = %d + 1 + %d: α = %08x, δ = %08x\n", rounds, r0, r1, in, out)
+		fmt.Printf("  p = 2^-%.2f, q = 2^-%.2f, BCT(β, γ) = %d/%d → previsto 2^-%.2f (trilha única)\n",
+			e0.Weight, e1.Weight, bct, samples, predicted)
+		fmt.Printf("  Empírico: %d/%d = 2^%.2f [%.3g, %.3g]\n",
+			hits, samples, math.Log2(float64(hits)/float64(samples)), lo, hi)
+
+		// Retângulo: os quartetos exigem colisão de 128 bits na saída, então
+		// N pares rendem N²·2^-128·p²·r·q² quartetos e só a estimativa é viável.
+		fmt.Printf("  Retângulo: 2^-%.2f por quarteto, ≈ 2^%.2f pares para um quarteto correto\n",
+			128+predicted, (128+predicted)/2)
+	}
+}
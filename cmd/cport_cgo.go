@@ -0,0 +1,49 @@
+//go:build cgo
+
+package main
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/.. -O2 -Wno-unused-result
+
+// As portas C trazem main() de exemplo e compartilham nomes de funções
+// auxiliares; os renomes evitam conflitos na ligação.
+#define main ginga_c_example
+#include "c/ginga.c"
+#undef main
+
+#define main ginga_hash_c_example
+#define rotl32 ginga_hash_rotl32
+#define confuse32 ginga_hash_confuse32
+#define round32 ginga_hash_round32
+#define subKey32 ginga_hash_subKey32
+#include "hash/c/ginga.c"
+#undef main
+*/
+import "C"
+
+import "unsafe"
+
+func ptr(b []byte) *C.uint8_t { return (*C.uint8_t)(unsafe.Pointer(&b[0])) }
+
+// cTimingTargets cronometra a porta C (c/ginga.c e hash/c/ginga.c) via cgo;
+// o custo da chamada cgo é o mesmo para as duas classes.
+func cTimingTargets() []timingTarget {
+	out := make([]byte, 32)
+	return []timingTarget{
+		{"C ginga_block_encrypt", 32, 16, func(key, in []byte) {
+			C.ginga_block_encrypt(ptr(in), ptr(key), ptr(out))
+		}},
+		{"C ginga_hash", 0, 64, func(_, in []byte) {
+			C.ginga_hash(ptr(in), C.size_t(len(in)), ptr(out))
+		}},
+	}
+}
+
+// cCTR cifra src com ginga_ctr_crypt da porta C.
+func cCTR(key, iv, src []byte) []byte {
+	dst := make([]byte, len(src))
+	if len(src) > 0 {
+		C.ginga_ctr_crypt(ptr(src), ptr(key), ptr(dst), C.size_t(len(src)), ptr(iv))
+	}
+	return dst
+}
@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package main
+
+// cTimingTargets não tem alvos sem cgo: a porta C não pode ser chamada.
+func cTimingTargets() []timingTarget { return nil }
+
+// cCTR devolve nil sem cgo: não há com o que comparar.
+func cCTR(key, iv, src []byte) []byte { return nil }
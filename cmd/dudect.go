@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pedroalbanese/ginga"
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// ============ TEMPO CONSTANTE (DUDECT) ============
+//
+// Metodologia do dudect (Reparaz, Balasch e Verbauwhede, 2017): cada medida
+// sorteia uma classe, fixa (entrada toda zero) ou aleatória, e cronometra a
+// operação. O teste t de Welch compara os tempos das duas classes, inteiros e
+// cortados em percentis crescentes para descartar medidas infladas por
+// interrupções e GC. |t| > 4.5 indica dependência do tempo em relação aos
+// dados. A porta PHP fica de fora: exigiria o interpretador.
+
+var dudectSamples = flag.Int("dudect-samples", 1<<17, "medidas por alvo no teste de tempo constante")
+
+const (
+	dudectThreshold = 4.5
+	dudectReps      = 8 // chamadas por medida, para ficar acima da resolução do relógio
+)
+
+// timingTarget é uma operação cronometrada; keyLen = 0 indica operação sem
+// chave (hash), em que só a entrada varia.
+type timingTarget struct {
+	name   string
+	keyLen int
+	inLen  int
+	run    func(key, in []byte)
+}
+
+func goTimingTargets() []timingTarget {
+	out := make([]byte, ginga.BlockSize)
+	return []timingTarget{
+		{"Go ginga.Encrypt", 32, ginga.BlockSize, func(key, in []byte) {
+			ct, _ := ginga.Encrypt(in, key)
+			copy(out, ct)
+		}},
+		{"Go ginga.Decrypt", 32, ginga.BlockSize, func(key, in []byte) {
+			pt, _ := ginga.Decrypt(in, key)
+			copy(out, pt)
+		}},
+		{"Go cipher.Block", 32, ginga.BlockSize, func(key, in []byte) {
+			block, _ := ginga.NewCipher(key)
+			block.Encrypt(out, in)
+		}},
+		{"Go GingaHash", 0, 2 * gingahash.BlockSize, func(_, in []byte) {
+			h := gingahash.New()
+			h.Write(in)
+			h.Sum(out[:0])
+		}},
+	}
+}
+
+// welchT devolve a estatística t de Welch entre as classes 0 e 1.
+func welchT(times []float64, class []uint8) float64 {
+	var n, mean, m2 [2]float64
+	for i, t := range times {
+		c := class[i]
+		n[c]++
+		d := t - mean[c]
+		mean[c] += d / n[c]
+		m2[c] += d * (t - mean[c])
+	}
+	if n[0] < 2 || n[1] < 2 {
+		return 0
+	}
+	v0, v1 := m2[0]/(n[0]-1), m2[1]/(n[1]-1)
+	return (mean[0] - mean[1]) / math.Sqrt(v0/n[0]+v1/n[1])
+}
+
+// dudectCrops são os percentis de corte do dudect, 1 - 0.5^(10(i+1)/N).
+func dudectCrops(n int) []float64 {
+	crops := make([]float64, n)
+	for i := range crops {
+		crops[i] = 1 - math.Pow(0.5, 10*float64(i+1)/float64(n))
+	}
+	return crops
+}
+
+// measureTiming cronometra a operação com a variável escolhida (chave ou
+// entrada) fixa ou aleatória conforme a classe; a outra é sempre aleatória.
+func measureTiming(t timingTarget, varyKey bool, samples int) (times []float64, class []uint8) {
+	class = make([]uint8, samples)
+	keys := make([]byte, samples*t.keyLen)
+	ins := make([]byte, samples*t.inLen)
+	copy(keys, randomBytes(len(keys)))
+	copy(ins, randomBytes(len(ins)))
+	coins := randomBytes(samples)
+	for i := range class {
+		class[i] = coins[i] & 1
+		if class[i] == 1 {
+			continue
+		}
+		if varyKey {
+			clear(keys[i*t.keyLen : (i+1)*t.keyLen])
+		} else {
+			clear(ins[i*t.inLen : (i+1)*t.inLen])
+		}
+	}
+
+	// Aquecimento: caches, preditor de desvios e frequência da CPU.
+	for i := 0; i < min(samples, 1024); i++ {
+		t.run(keys[i*t.keyLen:(i+1)*t.keyLen], ins[i*t.inLen:(i+1)*t.inLen])
+	}
+
+	times = make([]float64, samples)
+	for i := range times {
+		key, in := keys[i*t.keyLen:(i+1)*t.keyLen], ins[i*t.inLen:(i+1)*t.inLen]
+		start := time.Now()
+		for r := 0; r < dudectReps; r++ {
+			t.run(key, in)
+		}
+		times[i] = float64(time.Since(start).Nanoseconds())
+	}
+	return times, class
+}
+
+// dudect devolve o maior |t| entre as medidas inteiras e os cortes, e o corte
+// em que ocorreu (1 = sem corte).
+func dudect(times []float64, class []uint8) (maxT, crop float64) {
+	maxT, crop = math.Abs(welchT(times, class)), 1
+	sorted := slices.Clone(times)
+	slices.Sort(sorted)
+	for _, p := range dudectCrops(10) {
+		limit := sorted[int(p*float64(len(sorted)-1))]
+		var ct []float64
+		var cc []uint8
+		for i, t := range times {
+			if t <= limit {
+				ct = append(ct, t)
+				cc = append(cc, class[i])
+			}
+		}
+		if t := math.Abs(welchT(ct, cc)); t > maxT {
+			maxT, crop = t, p
+		}
+	}
+	return maxT, crop
+}
+
+func testConstantTime(samples int) {
+	fmt.Printf("\n⏱️  Tempo constante (dudect, %d medidas de %d chamadas, limiar |t| > %.1f):\n",
+		samples, dudectReps, dudectThreshold)
+
+	cTargets := cTimingTargets()
+	if len(cTargets) == 0 {
+		fmt.Println("Porta C indisponível (compilado sem cgo)")
+	}
+	crops := len(dudectCrops(10)) + 1
+	for _, t := range append(goTimingTargets(), cTargets...) {
+		variants := []bool{false}
+		if t.keyLen > 0 {
+			variants = append(variants, true)
+		}
+		for _, varyKey := range variants {
+			what := "entrada"
+			if varyKey {
+				what = "chave"
+			}
+			times, class := measureTiming(t, varyKey, samples)
+			maxT, crop := dudect(times, class)
+			mark := "✅"
+			if maxT > dudectThreshold {
+				mark = "⚠️  possível vazamento"
+			}
+			fmt.Printf("%-22s %-8s max |t| = %6.2f (percentil %.3f) %s\n", t.name, what, maxT, crop, mark)
+
+			// Šidák sobre os cortes: o máximo de |t| não é um teste único.
+			p := -math.Expm1(float64(crops) * math.Log1p(-normalTwoSided(maxT)))
+			record(fmt.Sprintf("Tempo/%s/%s", t.name, what), p)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/aes"
+	"fmt"
+
+	"github.com/pedroalbanese/ginga/fpe"
+)
+
+// ============ FPE (FF1) ============
+
+// fpeVector: chave, alfabeto, ajuste, texto claro e texto cifrado.
+type fpeVector struct {
+	key, alphabet, tweak, pt, ct string
+}
+
+// Exemplos 1, 2, 3, 7 e 9 do NIST para FF1 com AES.
+var fpeAESVectors = []fpeVector{
+	{"2B7E151628AED2A6ABF7158809CF4F3C", fpe.Digits, "", "0123456789", "2433477484"},
+	{"2B7E151628AED2A6ABF7158809CF4F3C", fpe.Digits, "39383736353433323130", "0123456789", "6124200773"},
+	{"2B7E151628AED2A6ABF7158809CF4F3C", fpe.Base36, "3737373770717273373737", "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+	{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", fpe.Digits, "", "0123456789", "6657667009"},
+	{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", fpe.Base36, "3737373770717273373737", "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+}
+
+// Vetores Ginga com chave 000102...1F.
+var fpeGingaVectors = []fpeVector{
+	{"", fpe.Digits, "", "0123456789", "5391079559"},
+	{"", fpe.Digits, "", "4111111111111111", "4070855870595208"},
+	{"", fpe.Digits, "39383736353433323130", "0123456789", "1972662857"},
+	{"", fpe.Base36, "3737373770717273373737", "0123456789abcdefghi", "r668v4kbs4uvjwj0d5q"},
+}
+
+func checkFPEVector(name string, c *fpe.Cipher, v fpeVector) bool {
+	ct, err := c.Encrypt(v.pt)
+	if err != nil || ct != v.ct {
+		fmt.Printf("❌ %s: esperado %s, obtido %s (%v)\n", name, v.ct, ct, err)
+		return false
+	}
+	if pt, err := c.Decrypt(ct); err != nil || pt != v.pt {
+		fmt.Printf("❌ %s: Decrypt não recupera o texto claro\n", name)
+		return false
+	}
+	return true
+}
+
+func testFPE() {
+	fmt.Println("\n🔢 FPE: FF1 com Ginga")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+
+	for i, v := range fpeAESVectors {
+		b, _ := aes.NewCipher(mustHex(v.key))
+		c, err := fpe.NewWithCipher(b, v.alphabet, mustHex(v.tweak))
+		if err != nil {
+			fail("FF1-AES vetor %d: %v", i+1, err)
+		} else if !checkFPEVector(fmt.Sprintf("FF1-AES vetor %d", i+1), c, v) {
+			failures++
+		}
+	}
+	for i, v := range fpeGingaVectors {
+		c, err := fpe.New(seq(32), v.alphabet, mustHex(v.tweak))
+		if err != nil {
+			fail("FF1-Ginga vetor %d: %v", i+1, err)
+		} else if !checkFPEVector(fmt.Sprintf("FF1-Ginga vetor %d", i+1), c, v) {
+			failures++
+		}
+	}
+
+	// Ida e volta em várias bases, comprimentos e ajustes; o resultado tem o
+	// mesmo comprimento em símbolos.
+	key := randomBytes(32)
+	for _, alphabet := range []string{"01", fpe.Digits, fpe.HexLower, fpe.Base36, fpe.Alphanumeric, "αβγδεζηθικλμνξοπ"} {
+		c, _ := fpe.New(key, alphabet, nil)
+		symbols := []rune(alphabet)
+		for n := c.FF1().MinLen(); n <= c.FF1().MinLen()+33; n += 3 {
+			msg := make([]rune, n)
+			for i, b := range randomBytes(n) {
+				msg[i] = symbols[int(b)%len(symbols)]
+			}
+			for _, tweak := range [][]byte{nil, randomBytes(1), randomBytes(15), randomBytes(40)} {
+				ct, err := c.WithTweak(tweak).Encrypt(string(msg))
+				if err != nil {
+					fail("base %d, %d símbolos: %v", len(symbols), n, err)
+					continue
+				}
+				if got := []rune(ct); len(got) != n {
+					fail("base %d: comprimento %d → %d", len(symbols), n, len(got))
+				}
+				if pt, err := c.WithTweak(tweak).Decrypt(ct); err != nil || pt != string(msg) {
+					fail("base %d, %d símbolos: ida e volta falhou", len(symbols), n)
+				}
+			}
+		}
+	}
+
+	// Comprimento mínimo: radix^minLen ≥ 10^6.
+	for radix, want := range map[int]int{2: 20, 10: 6, 36: 4, 999: 3, 1000: 2, 1 << 16: 2} {
+		if f, err := fpe.NewFF1(key, radix); err != nil {
+			fail("base %d: %v", radix, err)
+		} else if f.MinLen() != want {
+			fail("base %d: comprimento mínimo %d; esperado %d", radix, f.MinLen(), want)
+		}
+	}
+
+	// Numerais da base 65536, o maior alfabeto aceito.
+	ff1, _ := fpe.NewFF1(key, 1<<16)
+	x := make([]uint16, 7)
+	for i := range x {
+		x[i] = uint16(i * 9973)
+	}
+	if y, err := ff1.EncryptNumerals(x, []byte("t")); err != nil {
+		fail("base 65536: %v", err)
+	} else if z, err := ff1.DecryptNumerals(y, []byte("t")); err != nil || fmt.Sprint(z) != fmt.Sprint(x) {
+		fail("base 65536: ida e volta falhou")
+	}
+
+	// Entradas inválidas.
+	c, _ := fpe.New(key, fpe.Digits, nil)
+	if _, err := c.Encrypt("12345"); err == nil {
+		fail("aceitou mensagem abaixo do comprimento mínimo (%d)", c.FF1().MinLen())
+	}
+	if _, err := c.Encrypt("1234-5678"); err == nil {
+		fail("aceitou símbolo fora do alfabeto")
+	}
+	for _, alphabet := range []string{"", "0", "00123"} {
+		if _, err := fpe.New(key, alphabet, nil); err == nil {
+			fail("aceitou o alfabeto %q", alphabet)
+		}
+	}
+	a, _ := c.Encrypt("4111111111111111")
+	b, _ := c.WithTweak([]byte("cliente 42")).Encrypt("4111111111111111")
+	if a == b {
+		fail("o ajuste não muda o texto cifrado")
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ Vetores do NIST (FF1-AES) e Ginga conferem; ida e volta em bases de 2 a 65536")
+	} else {
+		fmt.Printf("❌ %d verificações de FPE falharam\n", failures)
+	}
+}
@@ -0,0 +1,320 @@
+// Comando ginga cifra e decifra arquivos no formato gingafile, para um ou
+// mais destinatários X25519 ou com senha.
+//
+//	ginga -keygen [-o identidade.txt]
+//	ginga -r gingapub1... [-r ...] [-o saída] [entrada]
+//	ginga -p [-o saída] [entrada]
+//	ginga -d -i identidade.txt [-i ...] [-o saída] [entrada]
+//	ginga -d [-o saída] [entrada]            (pede a senha)
+//
+// Sem entrada, lê da entrada padrão; sem -o, escreve na saída padrão. A
+// senha vem de GINGA_PASSPHRASE ou é pedida no terminal.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pedroalbanese/ginga/gingafile"
+)
+
+// multiFlag acumula as ocorrências repetidas de uma flag.
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ",") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
+
+func main() {
+	var recipients, identities multiFlag
+	flag.Var(&recipients, "r", "destinatário (gingapub1...); pode repetir")
+	flag.Var(&identities, "i", "arquivo de identidade para decifrar; pode repetir")
+	decrypt := flag.Bool("d", false, "decifrar")
+	passphrase := flag.Bool("p", false, "cifrar com senha")
+	keygen := flag.Bool("keygen", false, "gerar uma identidade X25519")
+	output := flag.String("o", "", "arquivo de saída (padrão: saída padrão)")
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		fatalf("no máximo um arquivo de entrada")
+	}
+	switch {
+	case *keygen:
+		if *decrypt || *passphrase || len(recipients) > 0 || len(identities) > 0 || flag.NArg() > 0 {
+			fatalf("-keygen não se combina com outras flags além de -o")
+		}
+	case *decrypt:
+		if *passphrase || len(recipients) > 0 {
+			fatalf("-r e -p só valem para cifrar")
+		}
+	default:
+		if len(identities) > 0 {
+			fatalf("-i só vale com -d")
+		}
+		if *passphrase == (len(recipients) > 0) {
+			fatalf("para cifrar, use -r ou -p (e não os dois)")
+		}
+	}
+
+	var err error
+	if *keygen {
+		err = runKeygen(*output)
+	} else {
+		err = run(*decrypt, *output, recipients, identities, *passphrase)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// run cifra ou decifra da entrada para a saída. Tudo que pode falhar antes
+// do primeiro byte de saída (destinatários, identidades, senha) é conferido
+// antes de criar o arquivo de saída, e numa falha o arquivo é removido.
+func run(decrypt bool, output string, recipients, identities []string, passphrase bool) error {
+	in := io.Reader(os.Stdin)
+	if flag.NArg() == 1 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+		if output != "" && sameFile(f, output) {
+			return errors.New("o arquivo de saída é o próprio arquivo de entrada")
+		}
+	}
+
+	out := io.Writer(os.Stdout)
+	var lazy *lazyFile
+	if output != "" {
+		lazy = &lazyFile{name: output}
+		out = lazy
+	}
+
+	var err error
+	if decrypt {
+		err = runDecrypt(in, out, identities)
+	} else {
+		err = runEncrypt(in, out, recipients, passphrase)
+	}
+	if lazy != nil {
+		err = lazy.finish(err)
+	}
+	return err
+}
+
+func sameFile(in *os.File, output string) bool {
+	a, err := in.Stat()
+	if err != nil {
+		return false
+	}
+	b, err := os.Stat(output)
+	return err == nil && os.SameFile(a, b)
+}
+
+// lazyFile só cria o arquivo na primeira escrita, para que um erro anterior
+// não apague um arquivo existente.
+type lazyFile struct {
+	name string
+	f    *os.File
+}
+
+func (l *lazyFile) Write(p []byte) (int, error) {
+	if l.f == nil {
+		f, err := os.Create(l.name)
+		if err != nil {
+			return 0, err
+		}
+		l.f = f
+	}
+	return l.f.Write(p)
+}
+
+// finish fecha o arquivo; se err não for nil ou o fechamento falhar, remove
+// o que foi escrito. Sem nenhuma escrita e sem erro, cria o arquivo vazio.
+func (l *lazyFile) finish(err error) error {
+	if err == nil && l.f == nil {
+		_, err = l.Write(nil)
+	}
+	if l.f == nil {
+		return err
+	}
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(l.name)
+	}
+	return err
+}
+
+func runKeygen(output string) error {
+	id, err := gingafile.GenerateX25519Identity()
+	if err != nil {
+		return err
+	}
+	out := io.Writer(os.Stdout)
+	if output != "" {
+		f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if _, err := fmt.Fprintf(out, "# criada em: %s\n# chave pública: %s\n%s\n",
+		time.Now().Format(time.RFC3339), id.Recipient(), id); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Chave pública: %s\n", id.Recipient())
+	return nil
+}
+
+func runEncrypt(in io.Reader, out io.Writer, recipients []string, passphrase bool) error {
+	var rs []gingafile.Recipient
+	for _, s := range recipients {
+		r, err := gingafile.ParseX25519Recipient(s)
+		if err != nil {
+			return fmt.Errorf("destinatário %q: %v", s, err)
+		}
+		rs = append(rs, r)
+	}
+	if passphrase {
+		pass, err := readPassphrase(true)
+		if err != nil {
+			return err
+		}
+		r, err := gingafile.NewScryptRecipient(pass)
+		if err != nil {
+			return err
+		}
+		rs = append(rs, r)
+	}
+
+	w, err := gingafile.Encrypt(out, rs...)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func runDecrypt(in io.Reader, out io.Writer, identityFiles []string) error {
+	var ids []gingafile.Identity
+	for _, name := range identityFiles {
+		fileIDs, err := parseIdentityFile(name)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, fileIDs...)
+	}
+	if len(ids) == 0 {
+		pass, err := readPassphrase(false)
+		if err != nil {
+			return err
+		}
+		id, err := gingafile.NewScryptIdentity(pass)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	r, err := gingafile.Decrypt(in, ids...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// parseIdentityFile lê uma identidade por linha; linhas vazias e as que
+// começam com # são ignoradas.
+func parseIdentityFile(name string) ([]gingafile.Identity, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ids []gingafile.Identity
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := gingafile.ParseX25519Identity(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, n, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s: nenhuma identidade", name)
+	}
+	return ids, nil
+}
+
+// readPassphrase lê a senha de GINGA_PASSPHRASE ou do terminal; o eco é
+// desligado com stty quando possível. Com confirm, a senha digitada no
+// terminal é pedida duas vezes, para que um erro de digitação não tranque o
+// arquivo.
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv("GINGA_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", errors.New("sem terminal para pedir a senha; defina GINGA_PASSPHRASE")
+	}
+	defer tty.Close()
+
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = tty
+		return cmd.Run()
+	}
+	if stty("-echo") == nil {
+		defer stty("echo")
+	}
+	r := bufio.NewReader(tty)
+	prompt := func(label string) (string, error) {
+		fmt.Fprint(tty, label)
+		line, err := r.ReadString('\n')
+		fmt.Fprintln(tty)
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	pass, err := prompt("Senha: ")
+	if err != nil || !confirm {
+		return pass, err
+	}
+	again, err := prompt("Confirme a senha: ")
+	if err != nil {
+		return "", err
+	}
+	if again != pass {
+		return "", errors.New("as senhas não conferem")
+	}
+	return pass, nil
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "ginga: "+format+"\n", args...)
+	os.Exit(1)
+}
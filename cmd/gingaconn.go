@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pedroalbanese/ginga/gingaconn"
+)
+
+// ============ CANAL SEGURO (gingaconn) ============
+
+// tamperConn inverte um bit do byte de índice flipAt escrito na conexão,
+// como um atacante no meio do caminho.
+type tamperConn struct {
+	net.Conn
+	flipAt, written int
+}
+
+func (t *tamperConn) Write(p []byte) (int, error) {
+	if i := t.flipAt - t.written; i >= 0 && i < len(p) {
+		p = bytes.Clone(p)
+		p[i] ^= 0x20
+	}
+	t.written += len(p)
+	return t.Conn.Write(p)
+}
+
+func newStaticKey() *ecdh.PrivateKey {
+	k, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// echoSession conecta cliente e servidor por net.Pipe: o cliente envia msg
+// e o servidor a devolve. Devolve o eco recebido pelo cliente e os erros de
+// cada lado.
+func echoSession(cli, srv *gingaconn.Config, msg []byte, wrapClient func(net.Conn) net.Conn) (echo []byte, client *gingaconn.Conn, cliErr, srvErr error) {
+	a, b := net.Pipe()
+	client = gingaconn.Client(wrapClient(a), cli)
+	server := gingaconn.Server(b, srv)
+
+	done := make(chan error, 1)
+	go func() {
+		defer server.Close()
+		buf := make([]byte, len(msg))
+		if _, err := io.ReadFull(server, buf); err != nil {
+			done <- err
+			return
+		}
+		_, err := server.Write(buf)
+		done <- err
+	}()
+
+	go func() {
+		if _, err := client.Write(msg); err != nil {
+			client.Close()
+		}
+	}()
+	echo = make([]byte, len(msg))
+	_, cliErr = io.ReadFull(client, echo)
+	// Depois do eco, o servidor fecha: o cliente vê o registro de
+	// fechamento como io.EOF.
+	if cliErr == nil {
+		if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+			cliErr = fmt.Errorf("fim do fluxo: %v; esperado io.EOF", err)
+		}
+	}
+	client.Close()
+	return echo, client, cliErr, <-done
+}
+
+func testGingaConn() {
+	fmt.Println("\n🔌 Canal seguro sobre net.Conn (X25519 + HKDF-GingaHash + Ginga-OCB3)")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+	plain := func(c net.Conn) net.Conn { return c }
+
+	cliKey, srvKey, strangerKey := newStaticKey(), newStaticKey(), newStaticKey()
+	cli := &gingaconn.Config{PrivateKey: cliKey, PeerKeys: []*ecdh.PublicKey{srvKey.PublicKey()}, RekeyInterval: 3}
+	srv := &gingaconn.Config{PrivateKey: srvKey, PeerKeys: []*ecdh.PublicKey{strangerKey.PublicKey(), cliKey.PublicKey()}, RekeyInterval: 3}
+
+	// Eco de 10 registros: com troca de chave a cada 3, os dois sentidos
+	// passam por três épocas.
+	msg := randomBytes(9*gingaconn.MaxRecordSize + 123)
+	echo, client, cliErr, srvErr := echoSession(cli, srv, msg, plain)
+	switch {
+	case cliErr != nil || srvErr != nil:
+		fail("eco: cliente %v, servidor %v", cliErr, srvErr)
+	case !bytes.Equal(echo, msg):
+		fail("eco não confere")
+	case !client.PeerKey().Equal(srvKey.PublicKey()):
+		fail("PeerKey não é a chave do servidor")
+	default:
+		if r, w := client.Epochs(); r != 3 || w != 3 {
+			fail("trocas de chave: leitura %d, escrita %d; esperado 3 e 3", r, w)
+		}
+	}
+
+	// Chave estática não fixada, em cada lado.
+	other := &gingaconn.Config{PrivateKey: strangerKey, PeerKeys: cli.PeerKeys}
+	if _, _, cliErr, srvErr := echoSession(other, &gingaconn.Config{PrivateKey: srvKey, PeerKeys: []*ecdh.PublicKey{cliKey.PublicKey()}}, msg[:10], plain); cliErr == nil || srvErr == nil {
+		fail("servidor aceitou cliente não fixado")
+	}
+	impostor := &gingaconn.Config{PrivateKey: strangerKey, PeerKeys: srv.PeerKeys}
+	if _, _, cliErr, _ := echoSession(cli, impostor, msg[:10], plain); cliErr == nil {
+		fail("cliente aceitou servidor não fixado")
+	}
+
+	// Adulteração no handshake (chave efêmera, chave estática e
+	// confirmação) e nos registros.
+	const handshakeBytes = 1 + 2*32 + 32
+	for _, at := range []int{5, 40, handshakeBytes - 1, handshakeBytes + 1, handshakeBytes + 40, handshakeBytes + 3*gingaconn.MaxRecordSize} {
+		_, _, cliErr, srvErr := echoSession(cli, srv, msg, func(c net.Conn) net.Conn { return &tamperConn{Conn: c, flipAt: at} })
+		if srvErr == nil || cliErr == nil {
+			fail("byte %d adulterado não foi detectado (cliente %v, servidor %v)", at, cliErr, srvErr)
+		}
+	}
+
+	// Conexão cortada num limite de registro, sem o registro de
+	// fechamento, não passa por fim normal.
+	a, b := net.Pipe()
+	client, server := gingaconn.Client(a, cli), gingaconn.Server(b, srv)
+	go func() {
+		server.Write(msg[:100])
+		b.Close()
+	}()
+	buf := make([]byte, 100)
+	if _, err := io.ReadFull(client, buf); err != nil {
+		fail("corte: registro antes do corte perdido: %v", err)
+	} else if _, err := client.Read(buf); err != io.ErrUnexpectedEOF {
+		fail("corte: %v; esperado io.ErrUnexpectedEOF", err)
+	}
+	client.Close()
+
+	// Close com um Write parado num par que não lê mais: o prazo do
+	// registro de fechamento também solta o Write, e Close retorna.
+	a, b = net.Pipe()
+	client, server = gingaconn.Client(a, cli), gingaconn.Server(b, srv)
+	go io.ReadFull(server, buf[:1])
+	written := make(chan error, 1)
+	go func() {
+		_, err := client.Write(msg)
+		written <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		if err := <-written; err == nil {
+			fail("Write parado terminou sem erro depois de Close")
+		}
+	case <-time.After(15 * time.Second):
+		fail("Close preso atrás de um Write bloqueado")
+	}
+	b.Close()
+
+	if failures == 0 {
+		fmt.Println("✅ Eco com troca de chaves, fechamento autenticado, fixação de chaves estáticas e detecção de adulteração conferem")
+	} else {
+		fmt.Printf("❌ %d verificações do canal seguro falharam\n", failures)
+	}
+}
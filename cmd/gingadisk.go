@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pedroalbanese/ginga/gingadisk"
+)
+
+// ============ DISPOSITIVO CIFRADO (gingadisk) ============
+
+// memStorage é um armazenamento em memória que cresce com as escritas.
+type memStorage struct{ b []byte }
+
+func (m *memStorage) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *memStorage) WriteAt(p []byte, off int64) (int, error) {
+	if end := off + int64(len(p)); end > int64(len(m.b)) {
+		m.b = append(m.b, make([]byte, end-int64(len(m.b)))...)
+	}
+	return copy(m.b[off:], p), nil
+}
+
+// failingStorage falha depois de writes escritas, como uma queda no meio de
+// uma operação.
+type failingStorage struct {
+	memStorage
+	writes int
+}
+
+func (f *failingStorage) WriteAt(p []byte, off int64) (int, error) {
+	if f.writes == 0 {
+		return 0, errors.New("queda simulada")
+	}
+	f.writes--
+	return f.memStorage.WriteAt(p, off)
+}
+
+// diskModel aplica escritas e leituras aleatórias ao dispositivo e a uma
+// cópia em claro, e confere as duas.
+func diskModel(d *gingadisk.Device, st *memStorage, ref []byte, blockSize, ops int) ([]byte, error) {
+	for op := 0; op < ops; op++ {
+		off := int64(randomWord()) % (int64(len(ref)) + 2*int64(blockSize) + 1)
+		n := int(randomWord() % uint32(3*blockSize+1))
+		if randomWord()&1 == 0 {
+			p := randomBytes(n)
+			if w, err := d.WriteAt(p, off); err != nil || w != n {
+				return nil, fmt.Errorf("escrita de %d bytes em %d: %d, %v", n, off, w, err)
+			}
+			if end := int(off) + n; end > len(ref) {
+				ref = append(ref, make([]byte, end-len(ref))...)
+			}
+			copy(ref[off:], p)
+		} else {
+			p := make([]byte, n)
+			got, err := d.ReadAt(p, off)
+			want := 0
+			if off < int64(len(ref)) {
+				want = copy(make([]byte, n), ref[off:])
+			}
+			if got != want || (got < n) != (err == io.EOF) || (err != nil && err != io.EOF) {
+				return nil, fmt.Errorf("leitura de %d bytes em %d: %d, %v; esperado %d", n, off, got, err, want)
+			}
+			if got > 0 && !bytes.Equal(p[:got], ref[off:off+int64(got)]) {
+				return nil, fmt.Errorf("leitura de %d bytes em %d não confere", n, off)
+			}
+		}
+		if d.Size() != int64(len(ref)) || d.StorageSize(d.Size()) != int64(len(st.b)) {
+			return nil, fmt.Errorf("tamanho %d (armazenamento %d); esperado %d", d.Size(), len(st.b), len(ref))
+		}
+	}
+	return ref, nil
+}
+
+func testGingaDisk() {
+	fmt.Println("\n💽 Dispositivo de blocos cifrado (io.ReaderAt/io.WriterAt, Ginga-OCB3 por bloco)")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+	key := randomBytes(32)
+
+	for _, bs := range []int{gingadisk.MinBlockSize, 100, gingadisk.DefaultBlockSize} {
+		st := new(memStorage)
+		d, err := gingadisk.New(st, 0, key, bs)
+		if err != nil {
+			panic(err)
+		}
+		ref, err := diskModel(d, st, nil, bs, 400)
+		if err != nil {
+			fail("bloco de %d bytes: %v", bs, err)
+			continue
+		}
+
+		// Reaberto só para leitura, o conteúdo é o mesmo.
+		ro, err := gingadisk.NewReader(st, int64(len(st.b)), key, bs)
+		all := make([]byte, len(ref))
+		if err != nil {
+			fail("bloco de %d bytes: reabertura: %v", bs, err)
+		} else if _, err := ro.ReadAt(all, 0); err != nil || !bytes.Equal(all, ref) {
+			fail("bloco de %d bytes: conteúdo reaberto não confere (%v)", bs, err)
+		} else if _, err := ro.WriteAt([]byte{1}, 0); err == nil {
+			fail("bloco de %d bytes: escrita aceita em dispositivo somente leitura", bs)
+		}
+		if other, err := gingadisk.NewReader(st, int64(len(st.b)), randomBytes(32), bs); err == nil {
+			if _, err := other.ReadAt(all[:1], 0); err == nil {
+				fail("bloco de %d bytes: lido com outra chave", bs)
+			}
+		}
+	}
+
+	// Adulteração, troca de blocos e truncamento, com blocos de 64 bytes.
+	const bs = 64
+	phys := int64(bs + gingadisk.Overhead)
+	st := new(memStorage)
+	d, _ := gingadisk.New(st, 0, key, bs)
+	msg := randomBytes(10*bs + 5)
+	d.WriteAt(msg, 0)
+	open := func(b []byte) (*gingadisk.Device, error) {
+		return gingadisk.NewReader(&memStorage{b: b}, int64(len(b)), key, bs)
+	}
+
+	bad := bytes.Clone(st.b)
+	bad[3*phys+20] ^= 1
+	dd, _ := open(bad)
+	var ie *gingadisk.IntegrityError
+	if _, err := dd.ReadAt(make([]byte, 10), 3*bs+5); !errors.As(err, &ie) || ie.Block != 3 {
+		fail("bloco 3 adulterado: %v", err)
+	}
+	// O acesso aleatório continua valendo para os outros blocos.
+	p := make([]byte, bs)
+	if _, err := dd.ReadAt(p, 5*bs); err != nil || !bytes.Equal(p, msg[5*bs:6*bs]) {
+		fail("bloco 5 ilegível depois de adulterar o 3 (%v)", err)
+	}
+
+	swapped := bytes.Clone(st.b)
+	copy(swapped[2*phys:3*phys], st.b[4*phys:5*phys])
+	copy(swapped[4*phys:5*phys], st.b[2*phys:3*phys])
+	dd, _ = open(swapped)
+	if _, err := dd.ReadAt(make([]byte, bs), 2*bs); err == nil {
+		fail("blocos trocados aceitos")
+	}
+
+	for _, cut := range []int64{10 * phys, 7 * phys, 7*phys + 30} {
+		dd, err := open(st.b[:cut])
+		if err != nil {
+			continue
+		}
+		if _, err := dd.ReadAt(make([]byte, dd.Size()), 0); err == nil {
+			fail("armazenamento truncado em %d aceito", cut)
+		}
+	}
+	if _, err := open(st.b[:5*phys+gingadisk.Overhead]); err == nil {
+		fail("último bloco sem texto claro aceito")
+	}
+
+	// Um tamanho menor que o real levaria a reusar nonces.
+	if _, err := gingadisk.New(st, 0, key, bs); err == nil {
+		fail("New aceitou tamanho menor que o do armazenamento")
+	}
+	if _, err := gingadisk.New(st, int64(len(st.b))-phys, key, bs); err == nil {
+		fail("New aceitou tamanho um bloco menor que o do armazenamento")
+	}
+
+	// Queda entre gravar o bloco novo e tirar a marca do antigo último
+	// bloco (a terceira escrita é a última que passa): o conteúdo continua
+	// legível.
+	fs := &failingStorage{writes: 3}
+	fd, _ := gingadisk.New(fs, 0, key, bs)
+	fd.WriteAt(msg[:2*bs], 0)
+	if _, err := fd.WriteAt(msg[2*bs:3*bs], 2*bs); err == nil {
+		fail("queda simulada não foi relatada")
+	}
+	p = make([]byte, 3*bs)
+	if dd, err := open(fs.b); err != nil {
+		fail("extensão interrompida: %v", err)
+	} else if _, err := dd.ReadAt(p, 0); err != nil || !bytes.Equal(p, msg[:3*bs]) {
+		fail("extensão interrompida deixou o dispositivo ilegível (%v)", err)
+	}
+
+	// Reescrever um bloco avança a versão e troca o texto cifrado.
+	before := bytes.Clone(st.b[phys : 2*phys])
+	d.WriteAt([]byte{msg[bs+1]}, bs+1)
+	if v := binary.BigEndian.Uint64(st.b[phys:]); v != 2 || bytes.Equal(before[8:], st.b[phys+8:2*phys]) {
+		fail("reescrita do bloco 1: versão %d, texto cifrado repetido %v", v, bytes.Equal(before[8:], st.b[phys+8:2*phys]))
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ Leituras e escritas parciais aleatórias, reabertura e detecção de adulteração, troca e truncamento conferem")
+	} else {
+		fmt.Printf("❌ %d verificações do dispositivo cifrado falharam\n", failures)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pedroalbanese/ginga/gingafile"
+)
+
+// ============ ARQUIVO CIFRADO (gingafile) ============
+
+func sealFile(msg []byte, rs ...gingafile.Recipient) []byte {
+	var buf bytes.Buffer
+	w, err := gingafile.Encrypt(&buf, rs...)
+	if err != nil {
+		panic(err)
+	}
+	// Escrita em pedaços irregulares, para cruzar os limites de bloco.
+	for len(msg) > 0 {
+		n := min(len(msg), 1000+len(msg)%7777)
+		if _, err := w.Write(msg[:n]); err != nil {
+			panic(err)
+		}
+		msg = msg[n:]
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func openFile(file []byte, ids ...gingafile.Identity) ([]byte, error) {
+	r, err := gingafile.Decrypt(bytes.NewReader(file), ids...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func testGingaFile() {
+	fmt.Println("\n📦 Arquivo cifrado para vários destinatários (X25519 / scrypt, HMAC-GingaHash, Ginga-OCB3)")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+
+	alice, _ := gingafile.GenerateX25519Identity()
+	bob, _ := gingafile.GenerateX25519Identity()
+	eve, _ := gingafile.GenerateX25519Identity()
+
+	// Chaves em texto voltam iguais.
+	if r, err := gingafile.ParseX25519Recipient(alice.Recipient().String()); err != nil || r.String() != alice.Recipient().String() {
+		fail("destinatário não volta igual do texto: %v", err)
+	}
+	if id, err := gingafile.ParseX25519Identity(alice.String()); err != nil || id.String() != alice.String() {
+		fail("identidade não volta igual do texto: %v", err)
+	}
+	for _, bad := range []string{"", "gingapub1", alice.String(), "GINGAPUB1" + alice.Recipient().String()[9:], alice.Recipient().String()[:20]} {
+		if _, err := gingafile.ParseX25519Recipient(bad); err == nil {
+			fail("destinatário malformado %q aceito", bad)
+		}
+	}
+
+	// Tamanhos em volta dos limites de bloco, para dois destinatários.
+	const chunk = gingafile.ChunkSize
+	for _, size := range []int{0, 1, chunk - 1, chunk, chunk + 1, 3 * chunk, 3*chunk + 17} {
+		msg := randomBytes(size)
+		file := sealFile(msg, alice.Recipient(), bob.Recipient())
+		for _, id := range []*gingafile.X25519Identity{alice, bob} {
+			if got, err := openFile(file, eve, id); err != nil || !bytes.Equal(got, msg) {
+				fail("%d bytes: decifração falhou (%v)", size, err)
+			}
+		}
+		var noMatch *gingafile.NoIdentityMatchError
+		if _, err := openFile(file, eve); !errors.As(err, &noMatch) {
+			fail("%d bytes: identidade alheia: %v", size, err)
+		}
+
+		// Truncar num limite de bloco, cortar um byte ou acrescentar lixo
+		// não pode passar despercebido.
+		payload := len(file) - size - (size/chunk+1)*16
+		if size%chunk == 0 && size > 0 {
+			payload += 16
+		}
+		cuts := [][]byte{file[:len(file)-1], append(bytes.Clone(file), 0)}
+		if size > chunk {
+			cuts = append(cuts, file[:payload+chunk+16])
+		}
+		for i, bad := range cuts {
+			if _, err := openFile(bad, alice); err == nil {
+				fail("%d bytes: arquivo alterado %d aceito", size, i)
+			}
+		}
+	}
+
+	// Adulteração em cada região: versão, stanza, HMAC e conteúdo.
+	msg := randomBytes(2*chunk + 5)
+	file := sealFile(msg, alice.Recipient(), bob.Recipient())
+	header := bytes.Index(file, []byte("\n--- ")) + 5
+	for _, at := range []int{3, 20, header - 10, header + 2, header + 60, len(file) - chunk, len(file) - 1} {
+		bad := bytes.Clone(file)
+		bad[at] ^= 0x01
+		if _, err := openFile(bad, bob); err == nil {
+			fail("byte %d adulterado não foi detectado", at)
+		}
+	}
+
+	// Senha: recupera com a certa, recusa a errada e o fator de trabalho
+	// acima do limite, e não se mistura com outros destinatários.
+	pw, _ := gingafile.NewScryptRecipient("capoeira de besouro")
+	pw.SetWorkFactor(10)
+	file = sealFile(msg, pw)
+	right, _ := gingafile.NewScryptIdentity("capoeira de besouro")
+	wrong, _ := gingafile.NewScryptIdentity("capoeira de angola")
+	if got, err := openFile(file, alice, right); err != nil || !bytes.Equal(got, msg) {
+		fail("senha certa não decifrou (%v)", err)
+	}
+	if _, err := openFile(file, wrong); err == nil {
+		fail("senha errada aceita")
+	}
+	right.SetMaxWorkFactor(9)
+	if _, err := openFile(file, right); err == nil {
+		fail("fator de trabalho acima do limite aceito")
+	}
+	if _, err := gingafile.Encrypt(io.Discard, pw, alice.Recipient()); err == nil {
+		fail("scrypt aceito junto com outro destinatário")
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ Vários destinatários, senha, limites de bloco, truncamento e adulteração conferem")
+	} else {
+		fmt.Printf("❌ %d verificações do arquivo cifrado falharam\n", failures)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ HCTR2 ============
+
+// polyvalP é x^128 + x^127 + x^126 + x^121 + 1, o polinômio do POLYVAL.
+var polyvalP = func() *big.Int {
+	p := big.NewInt(1)
+	for _, e := range []int{121, 126, 127, 128} {
+		p.SetBit(p, e, 1)
+	}
+	return p
+}()
+
+// leInt lê 16 bytes como polinômio: o bit i do byte j é o coeficiente de
+// x^(8j+i).
+func leInt(b []byte) *big.Int {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(r)
+}
+
+func leBytes(n *big.Int) []byte {
+	b := n.FillBytes(make([]byte, 16))
+	for i := 0; i < 8; i++ {
+		b[i], b[15-i] = b[15-i], b[i]
+	}
+	return b
+}
+
+// polyvalDot é a operação dot do RFC 8452, a·b·x^-128 mod P, escrita
+// direto da definição: produto sem vai-um seguido de 128 divisões por x.
+func polyvalDot(a, b *big.Int) *big.Int {
+	c := new(big.Int)
+	for i := 0; i < 128; i++ {
+		if b.Bit(i) == 1 {
+			c.Xor(c, new(big.Int).Lsh(a, uint(i)))
+		}
+	}
+	for i := 0; i < 128; i++ {
+		if c.Bit(0) == 1 {
+			c.Xor(c, polyvalP)
+		}
+		c.Rsh(c, 1)
+	}
+	return c
+}
+
+// polyvalRef é POLYVAL(H, X) para X com tamanho múltiplo de 16.
+func polyvalRef(h, x []byte) []byte {
+	hk, s := leInt(h), new(big.Int)
+	for ; len(x) > 0; x = x[16:] {
+		s = polyvalDot(s.Xor(s, leInt(x[:16])), hk)
+	}
+	return leBytes(s)
+}
+
+// hctr2Ref é HCTR2 escrito como no artigo, bloco a bloco, para conferir
+// ginga.HCTR2.
+func hctr2Ref(b cipher.Block, src, tweak []byte) []byte {
+	bin := func(i uint64) []byte {
+		out := make([]byte, 16)
+		binary.LittleEndian.PutUint64(out, i)
+		return out
+	}
+	enc := func(x []byte) []byte {
+		out := make([]byte, 16)
+		b.Encrypt(out, x)
+		return out
+	}
+	h, l := enc(bin(0)), enc(bin(1))
+	hash := func(m []byte) []byte {
+		in := bin(2*8*uint64(len(tweak)) + 2)
+		pm := append([]byte(nil), m...)
+		if len(m)%16 != 0 {
+			in = bin(2*8*uint64(len(tweak)) + 3)
+			pm = append(pm, 1)
+		}
+		in = append(in, tweak...)
+		in = append(in, make([]byte, (16-len(tweak)%16)%16)...)
+		in = append(in, pm...)
+		in = append(in, make([]byte, (16-len(pm)%16)%16)...)
+		return polyvalRef(h, in)
+	}
+
+	m, n := src[:16], src[16:]
+	mm := xorBytes(m, hash(n))
+	uu := enc(mm)
+	s := xorBytes(xorBytes(mm, uu), l)
+	v := make([]byte, len(n))
+	for i := 0; i < len(n); i += 16 {
+		ks := enc(xorBytes(s, bin(uint64(i/16+1))))
+		for j := i; j < len(n) && j < i+16; j++ {
+			v[j] = n[j] ^ ks[j-i]
+		}
+	}
+	return append(xorBytes(uu, hash(v)), v...)
+}
+
+// Vetores Ginga (chave 000102...1F, texto claro 00 01 02 ...): ajuste,
+// comprimento e texto cifrado.
+var hctr2GingaVectors = []struct {
+	tweak string
+	n     int
+	ct    string
+}{
+	{"", 16, "1b63d73a0284c22296a14e15b52d733e"},
+	{"", 17, "4115a146b312fe4ec8e9eab4475c6aa22e"},
+	{"000102030405060708090a0b0c0d0e0f", 32, "a20465098164a68667aee50509721bac8d16d182f21e514b577e948670413811"},
+	{"7477", 45, "5795fc5ce581f6ab7158a097e4d779f1b0da25d6cc7ce7caf283056c114db9e90c05d6a39ebf27a7ab005261cf"},
+}
+
+func testHCTR2() {
+	fmt.Println("\n📜 HCTR2: cifra de bloco larga")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+
+	// RFC 8452, apêndice A.
+	if got := polyvalRef(mustHex("25629347589242761d31f826ba4b757b"),
+		mustHex("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362")); !bytes.Equal(got, mustHex("f7a3b47b846119fae5b7866cf5e5b77e")) {
+		fail("POLYVAL de referência diverge do RFC 8452: %x", got)
+	}
+
+	gingaBlock, _ := ginga.NewCipher(seq(32))
+	hg, _ := ginga.NewHCTR2(gingaBlock)
+	for i, v := range hctr2GingaVectors {
+		got := make([]byte, v.n)
+		hg.Encrypt(got, seq(v.n), mustHex(v.tweak))
+		if !bytes.Equal(got, mustHex(v.ct)) {
+			fail("HCTR2-Ginga vetor %d: obtido %x", i+1, got)
+		}
+	}
+
+	// A implementação da biblioteca contra a de referência, com Ginga e AES.
+	aesBlock, _ := aes.NewCipher(randomBytes(32))
+	randBlock, _ := ginga.NewCipher(randomBytes(32))
+	for _, b := range []cipher.Block{randBlock, aesBlock} {
+		x, _ := ginga.NewHCTR2(b)
+		for n := 16; n <= 16*ginga.BlockSize+3; n += 5 {
+			for _, tl := range []int{0, 1, 15, 16, 17, 40} {
+				pt, tweak := randomBytes(n), randomBytes(tl)
+				ct := make([]byte, n)
+				x.Encrypt(ct, pt, tweak)
+				if !bytes.Equal(ct, hctr2Ref(b, pt, tweak)) {
+					fail("HCTR2 diverge da referência (%d bytes, ajuste de %d)", n, tl)
+					continue
+				}
+				// Decrypt no mesmo buffer.
+				x.Decrypt(ct, ct, tweak)
+				if !bytes.Equal(ct, pt) {
+					fail("HCTR2.Decrypt não inverte Encrypt (%d bytes, ajuste de %d)", n, tl)
+				}
+			}
+		}
+	}
+
+	// Bloco largo: um bit trocado no texto claro ou no ajuste deve mudar
+	// cerca de metade dos bits de todo o texto cifrado, inclusive do
+	// primeiro e do último bloco.
+	const n, trials = 100, 200
+	tweak := randomBytes(8)
+	worst := [2]float64{1, 1}
+	for t := 0; t < trials; t++ {
+		pt := randomBytes(n)
+		base := make([]byte, n)
+		hg.Encrypt(base, pt, tweak)
+		for which, flip := range []func() ([]byte, []byte){
+			func() ([]byte, []byte) { p := bytes.Clone(pt); p[t%n] ^= 1 << (t % 8); return p, tweak },
+			func() ([]byte, []byte) { w := bytes.Clone(tweak); w[t%len(w)] ^= 1 << (t % 8); return pt, w },
+		} {
+			p, w := flip()
+			ct := make([]byte, n)
+			hg.Encrypt(ct, p, w)
+			for _, part := range [][2]int{{0, 16}, {n - 16, n}} {
+				frac := float64(bitDiff(ct[part[0]:part[1]], base[part[0]:part[1]])) / 128
+				worst[which] = min(worst[which], frac)
+			}
+		}
+	}
+	fmt.Printf("Menor fração de bits alterados no primeiro ou no último bloco: texto claro %.2f, ajuste %.2f\n", worst[0], worst[1])
+	if worst[0] < 0.25 || worst[1] < 0.25 {
+		fail("um bit trocado não se espalhou por todo o texto cifrado")
+	}
+
+	if !panics(func() { hg.Encrypt(make([]byte, 15), make([]byte, 15), nil) }) {
+		fail("HCTR2 aceitou entrada menor que 16 bytes")
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ HCTR2 confere com a referência (Ginga e AES) e espalha um bit por toda a mensagem")
+	} else {
+		fmt.Printf("❌ %d verificações de HCTR2 falharam\n", failures)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ INTEGRAL / SQUARE ============
+//
+// Um conjunto estruturado percorre todos os valores nos bits ativos e fixa os
+// demais. Um bit de saída é balanceado quando a soma XOR sobre o conjunto é
+// zero para qualquer chave e qualquer valor dos bits constantes.
+
+var divisionBudget = flag.Int("division-budget", 1<<18, "nós por consulta na busca de trilhas de divisão")
+
+// integralSet descreve um conjunto estruturado pelos bits ativos.
+type integralSet struct {
+	name   string
+	active [4]uint32
+}
+
+var integralSets = []integralSet{
+	{"8 bits (byte baixo de x0)", [4]uint32{0xFF}},
+	{"16 bits (metade baixa de x0)", [4]uint32{0xFFFF}},
+	{"16 bits (byte baixo de x0 e x2)", [4]uint32{0xFF, 0, 0xFF}},
+	{"20 bits (x0[0..19])", [4]uint32{0xFFFFF}},
+	{"32 bits (x0)", [4]uint32{0xFFFFFFFF}},
+	{"127 bits (exceto x0[0])", [4]uint32{0xFFFFFFFE, 0xFFFFFFFF, 0xFFFFFFFF, 0xFFFFFFFF}},
+}
+
+// activeCount devolve o número de bits ativos do conjunto.
+func activeCount(active [4]uint32) int {
+	n := 0
+	for _, w := range active {
+		n += bits.OnesCount32(w)
+	}
+	return n
+}
+
+// IntegralSums cifra o conjunto (com constantes e chave aleatórias) e devolve
+// a soma XOR da saída após cada rodada 1..rounds.
+func IntegralSums(active [4]uint32, rounds int) [][4]uint32 {
+	k := loadKey(randomBytes(32))
+	base := loadState(randomBytes(16))
+	for i := range base {
+		base[i] &^= active[i]
+	}
+
+	// Os bits ativos são preenchidos pelos bits de um contador de d bits.
+	var pos []int
+	for i, w := range active {
+		for b := 0; b < 32; b++ {
+			if w>>b&1 == 1 {
+				pos = append(pos, 32*i+b)
+			}
+		}
+	}
+
+	sums := make([][4]uint32, rounds+1)
+	for c := uint64(0); c < 1<<len(pos); c++ {
+		x := base
+		for j, p := range pos {
+			x[p/32] |= uint32(c>>j&1) << (p % 32)
+		}
+		for r := 0; r < rounds; r++ {
+			x = encryptWords(x, &k, r, r+1)
+			for i := range x {
+				sums[r+1][i] ^= x[i]
+			}
+		}
+	}
+	return sums
+}
+
+// balancedBits conta os bits com soma zero em todas as tentativas.
+func balancedBits(nonzero [4]uint32) int {
+	n := 0
+	for _, w := range nonzero {
+		n += 32 - bits.OnesCount32(w)
+	}
+	return n
+}
+
+func testIntegral(trials, maxActive int) {
+	fmt.Printf("\n∑ Distinguidores integrais (Ginga, %d chaves por conjunto):\n", trials)
+
+	for _, set := range integralSets {
+		d := activeCount(set.active)
+		fmt.Printf("\nConjunto %s:\n", set.name)
+
+		var nonzero [][4]uint32
+		if d <= maxActive {
+			nonzero = make([][4]uint32, ginga.Rounds+1)
+			for t := 0; t < trials; t++ {
+				for r, s := range IntegralSums(set.active, ginga.Rounds) {
+					for i := range s {
+						nonzero[r][i] |= s[i]
+					}
+				}
+			}
+		}
+
+		div := NewDivisionSearch(*divisionBudget)
+		proofs, distinguisher := true, 0
+		for r := 1; r <= ginga.Rounds && (proofs || nonzero != nil); r++ {
+			var parts []string
+			if nonzero != nil {
+				parts = append(parts, fmt.Sprintf("empírico %3d/128 balanceados", balancedBits(nonzero[r])))
+			}
+			if proofs {
+				proven, unknown := div.Balanced(set.active, r)
+				part := fmt.Sprintf("divisão: %3d provados", proven)
+				if unknown > 0 {
+					part += fmt.Sprintf(" (%d indeterminados)", unknown)
+				}
+				parts = append(parts, part)
+				// Sem nenhum bit provado, as rodadas seguintes não terão.
+				proofs = proven > 0
+				if proofs {
+					distinguisher = r
+				}
+			}
+			fmt.Printf("Rodadas %2d: %s\n", r, strings.Join(parts, ", "))
+			if nonzero != nil && !proofs && balancedBits(nonzero[r]) == 0 {
+				break
+			}
+		}
+
+		fmt.Printf("Distinguidor integral provado pela propriedade de divisão: %d rodadas\n", distinguisher)
+
+		if nonzero != nil {
+			// H0: cada bit tem soma zero com probabilidade 1/2 por chave.
+			b := balancedBits(nonzero[ginga.Rounds])
+			record("Integral/"+set.name, binomialSF(b, 128, math.Pow(2, -float64(trials))))
+		}
+	}
+}
+
+// ============ PROPRIEDADE DE DIVISÃO (BIT A BIT) ============
+//
+// Busca de trilhas de divisão convencionais sobre vetores de 128 bits. Um
+// bit j de saída é balanceado se nenhuma trilha leva os bits ativos a um
+// vetor ≤ e_j. Regras usadas (todas sobreaproximam as trilhas, então
+// "balanceado" é provado; o contrário é apenas ausência de prova):
+//
+//	palavra   x ↦ ((x + k) ⊕ 0xA5A5A5A5) + 0x3C3C3C3C é triangular (y_i só
+//	          depende de x_0..x_i) e bijetiva: 0 → 0, cheio → cheio e u → e_j
+//	          para todo j ≥ topo(u); rotações e ⊕k apenas movem o vetor
+//	a ^= b⋘n  COPY + XOR exatos bit a bit; com mais de 8 bits livres, as
+//	          escolhas T são agrupadas pelo maior bit t, com saída
+//	          (a | e_t, b sem os bits livres ≤ t), que é ≤ a saída real
+//
+// A entrada da camada de palavras só importa por (zero, cheio, topo), o que
+// permite memorizar falhas de forma canônica. Duas podas vêm do alvo: o peso
+// não muda na camada linear, então a última rodada precisa começar com no
+// máximo uma palavra não nula e não cheia, e a penúltima sem palavras cheias.
+
+// divisionKey identifica um estado da busca: rodada, passo (0 = camada de
+// palavras, 1..4 = passos de mixState32) e vetor.
+type divisionKey struct {
+	stage int
+	v     [4]uint32
+}
+
+// DivisionSearch guarda o estado de uma consulta de trilhas de divisão.
+type DivisionSearch struct {
+	rounds  int
+	target  [4]uint32
+	failed  map[divisionKey]bool
+	nodes   int
+	budget  int
+	aborted bool
+}
+
+func NewDivisionSearch(budget int) *DivisionSearch {
+	return &DivisionSearch{budget: budget}
+}
+
+var (
+	divMixA = [5]int{0, 0, 1, 2, 3}
+	divMixB = [5]int{0, 1, 2, 3, 0}
+	divMixN = [5]int{0, 5, 11, 17, 23}
+)
+
+// Balanced devolve quantos bits de saída são comprovadamente balanceados
+// após rounds rodadas e em quantos a busca esgotou o orçamento.
+func (s *DivisionSearch) Balanced(active [4]uint32, rounds int) (proven, unknown int) {
+	for j := 0; j < 128; j++ {
+		s.rounds = rounds
+		s.target = [4]uint32{}
+		s.target[j/32] = 1 << (j % 32)
+		s.failed = map[divisionKey]bool{}
+		s.nodes, s.aborted = 0, false
+
+		found := s.search(0, 0, active)
+		switch {
+		case s.aborted:
+			unknown++
+		case !found:
+			proven++
+		}
+	}
+	return proven, unknown
+}
+
+func (s *DivisionSearch) search(r, st int, v [4]uint32) bool {
+	if s.aborted {
+		return false
+	}
+	if s.nodes++; s.nodes > s.budget {
+		s.aborted = true
+		return false
+	}
+	if r == s.rounds {
+		for i := range v {
+			if v[i]&^s.target[i] != 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case r == s.rounds-1 && st == 0:
+		w := 0
+		for _, x := range v {
+			if x == 0xFFFFFFFF {
+				return false
+			}
+			if x != 0 {
+				w++
+			}
+		}
+		if w > 1 {
+			return false
+		}
+	case r == s.rounds-1:
+		w := 0
+		for _, x := range v {
+			w += bits.OnesCount32(x)
+		}
+		if w > 1 {
+			return false
+		}
+	case r == s.rounds-2:
+		w := 0
+		for _, x := range v {
+			if x == 0xFFFFFFFF {
+				return false
+			}
+			w += bits.OnesCount32(x)
+		}
+		if st > 0 && w > 31 {
+			return false
+		}
+	}
+
+	if st == 0 {
+		for i, x := range v {
+			if x != 0 && x != 0xFFFFFFFF {
+				v[i] = 1 << (31 - bits.LeadingZeros32(x))
+			}
+		}
+	}
+	key := divisionKey{5*r + st, v}
+	if s.failed[key] {
+		return false
+	}
+	var ok bool
+	if st == 0 {
+		ok = s.words(r, 0, v, [4]uint32{})
+	} else {
+		ok = s.mix(r, st, v)
+	}
+	if !ok && !s.aborted {
+		s.failed[key] = true
+	}
+	return ok
+}
+
+// words escolhe a saída da palavra i na camada não linear da rodada r.
+func (s *DivisionSearch) words(r, i int, in, out [4]uint32) bool {
+	if i == 4 {
+		return s.search(r, 1, out)
+	}
+	u := in[i]
+	if u == 0 || u == 0xFFFFFFFF {
+		out[i] = u
+		return s.words(r, i+1, in, out)
+	}
+	rot := 7 + (r+3)&31 + (r+5)&31
+	for j := 31 - bits.LeadingZeros32(u); j < 32; j++ {
+		out[i] = gRotl(1<<j, rot)
+		if s.words(r, i+1, in, out) {
+			return true
+		}
+	}
+	return false
+}
+
+// mix aplica o passo st de mixState32, a ^= b ⋘ n.
+func (s *DivisionSearch) mix(r, st int, v [4]uint32) bool {
+	a, b, n := divMixA[st], divMixB[st], divMixN[st]
+	ua, ub := v[a], gRotl(v[b], n)
+	both, free := ua&ub, ub&^ua
+
+	next := func(a2, b2 uint32) bool {
+		w := v
+		w[a], w[b] = a2, gRotr(b2, n)
+		if st == 4 {
+			return s.search(r+1, 0, w)
+		}
+		return s.search(r, st+1, w)
+	}
+
+	if bits.OnesCount32(free) > 8 {
+		for rest := free; rest != 0; {
+			t := 31 - bits.LeadingZeros32(rest)
+			rest &^= 1 << t
+			low := free & (1<<t | (1<<t - 1))
+			if next(ua|1<<t, both|free&^low) {
+				return true
+			}
+		}
+		return next(ua, ub)
+	}
+
+	var rec func(rest, moved uint32) bool
+	rec = func(rest, moved uint32) bool {
+		if rest == 0 {
+			return next(ua|moved, both|free&^moved)
+		}
+		low := rest & -rest
+		return rec(rest&^low, moved) || rec(rest&^low, moved|low)
+	}
+	return rec(free, 0)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	gingahash "github.com/pedroalbanese/ginga/hash"
+	"github.com/pedroalbanese/ginga/jose"
+)
+
+// ============ JOSE (JWS / JWE) ============
+
+// b64url é base64url sem preenchimento, feito à mão a partir da base64
+// padrão, para não conferir o pacote jose com ele mesmo.
+func b64url(b []byte) string {
+	s := base64.StdEncoding.EncodeToString(b)
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return strings.TrimRight(s, "=")
+}
+
+// replacePart troca a parte i de um token compacto.
+func replacePart(token string, i int, part string) string {
+	parts := strings.Split(token, ".")
+	parts[i] = part
+	return strings.Join(parts, ".")
+}
+
+func testJOSE() {
+	fmt.Println("\n🪪 JOSE: JWS HG256 (HMAC-GingaHash), JWE G256KW/dir + G256OCB")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+
+	key := randomBytes(32)
+	claims := []byte(`{"sub":"mestre","iss":"roda","exp":1700000000}`)
+
+	// JWS: a assinatura é o HMAC-GingaHash da entrada de assinatura.
+	tok, err := jose.Sign(claims, key, jose.Header{"typ": "JWT", "kid": "k1"})
+	if err != nil {
+		panic(err)
+	}
+	h := b64url([]byte(`{"alg":"HG256","kid":"k1","typ":"JWT"}`))
+	m := hmac.New(gingahash.New, key)
+	m.Write([]byte(h + "." + b64url(claims)))
+	if want := h + "." + b64url(claims) + "." + b64url(m.Sum(nil)); tok != want {
+		fail("JWS difere da construção independente:\n%s\n%s", tok, want)
+	}
+	payload, hdr, err := jose.Verify(tok, key)
+	if err != nil || !bytes.Equal(payload, claims) || hdr["kid"] != "k1" || hdr.Alg() != jose.HG256 {
+		fail("JWS não confere (%v)", err)
+	}
+
+	parts := strings.Split(tok, ".")
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sig[0] ^= 1
+	for name, c := range map[string]struct {
+		token string
+		want  error
+	}{
+		"payload trocado":   {replacePart(tok, 1, b64url([]byte(`{"sub":"admin"}`))), jose.ErrVerification},
+		"assinatura errada": {replacePart(tok, 2, b64url(sig)), jose.ErrVerification},
+		"alg none":          {b64url([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".", jose.ErrUnsupportedAlgorithm},
+		"alg HS256":         {replacePart(tok, 0, b64url([]byte(`{"alg":"HS256"}`))), jose.ErrUnsupportedAlgorithm},
+		"sem alg":           {replacePart(tok, 0, b64url([]byte(`{"typ":"JWT"}`))), jose.ErrUnsupportedAlgorithm},
+		"com preenchimento": {tok + "=", jose.ErrMalformed},
+		"quatro partes":     {tok + ".x", jose.ErrMalformed},
+		"cabeçalho lista":   {replacePart(tok, 0, b64url([]byte(`["alg"]`))), jose.ErrMalformed},
+	} {
+		if _, _, err := jose.Verify(c.token, key); !errors.Is(err, c.want) {
+			fail("JWS %s: %v; esperado %v", name, err, c.want)
+		}
+	}
+	if _, _, err := jose.Verify(tok, randomBytes(32)); !errors.Is(err, jose.ErrVerification) {
+		fail("JWS aceito com outra chave")
+	}
+	crit := b64url([]byte(`{"alg":"HG256","crit":["exp"],"exp":1}`))
+	m = hmac.New(gingahash.New, key)
+	m.Write([]byte(crit + "." + parts[1]))
+	if _, _, err := jose.Verify(crit+"."+parts[1]+"."+b64url(m.Sum(nil)), key); err == nil {
+		fail("JWS com crit aceito")
+	}
+	if _, err := jose.Sign(claims, key[:31], nil); err == nil {
+		fail("JWS aceitou chave de 31 bytes")
+	}
+	if _, err := jose.Sign(claims, key, jose.Header{"alg": "none"}); err == nil {
+		fail("JWS aceitou alg conflitante no cabeçalho")
+	}
+
+	// JWE: os dois modos de chave, texto vazio e longo.
+	for _, alg := range []string{jose.G256KW, jose.Direct} {
+		for _, size := range []int{0, 1, 16, 1000} {
+			msg := randomBytes(size)
+			tok, err := jose.Encrypt(msg, alg, key, jose.Header{"cty": "JWT"})
+			if err != nil {
+				panic(err)
+			}
+			pt, hdr, err := jose.Decrypt(tok, key)
+			if err != nil || !bytes.Equal(pt, msg) || hdr.Alg() != alg || hdr.Enc() != jose.G256OCB || hdr["cty"] != "JWT" {
+				fail("JWE %s, %d bytes: não confere (%v)", alg, size, err)
+			}
+			if _, _, err := jose.Decrypt(tok, randomBytes(32)); !errors.Is(err, jose.ErrVerification) {
+				fail("JWE %s aceito com outra chave: %v", alg, err)
+			}
+			// Um bit trocado em qualquer parte é rejeitado.
+			parts := strings.Split(tok, ".")
+			for i, p := range parts {
+				raw, _ := base64.RawURLEncoding.DecodeString(p)
+				if len(raw) == 0 {
+					continue
+				}
+				raw[len(raw)/2] ^= 0x04
+				if _, _, err := jose.Decrypt(replacePart(tok, i, b64url(raw)), key); err == nil {
+					fail("JWE %s, %d bytes: parte %d adulterada aceita", alg, size, i)
+				}
+			}
+		}
+	}
+
+	tok, _ = jose.Encrypt(claims, jose.Direct, key, nil)
+	for name, hdr := range map[string]string{
+		"enc desconhecido":   `{"alg":"dir","enc":"A256GCM"}`,
+		"alg desconhecido":   `{"alg":"RSA-OAEP","enc":"G256OCB"}`,
+		"zip":                `{"alg":"dir","enc":"G256OCB","zip":"DEF"}`,
+		"crit":               `{"alg":"dir","enc":"G256OCB","crit":["x"],"x":1}`,
+		"cabeçalho inválido": `{"alg":`,
+	} {
+		if _, _, err := jose.Decrypt(replacePart(tok, 0, b64url([]byte(hdr))), key); err == nil {
+			fail("JWE com %s aceito", name)
+		}
+	}
+	if _, _, err := jose.Decrypt(replacePart(tok, 1, b64url(randomBytes(40))), key); !errors.Is(err, jose.ErrMalformed) {
+		fail("JWE dir com chave cifrada não vazia: %v", err)
+	}
+	if _, _, err := jose.Verify(tok, key); err == nil {
+		fail("JWE aceito como JWS")
+	}
+	if _, err := jose.Encrypt(claims, jose.HG256, key, nil); !errors.Is(err, jose.ErrUnsupportedAlgorithm) {
+		fail("JWE aceitou alg HG256")
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ JWS e JWE compactos, validação de alg/enc e rejeição de adulterações conferem")
+	} else {
+		fmt.Printf("❌ %d verificações de JOSE falharam\n", failures)
+	}
+}
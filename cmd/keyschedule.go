@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ KEY AVALANCHE ============
+
+// testKeyAvalanche inverte cada bit da chave com o plaintext fixo e mede
+// quantos bits do ciphertext mudam. O p-valor é o do pior bit da chave,
+// corrigido por Šidák.
+func testKeyAvalanche(name string, encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize, samples int) {
+	fmt.Printf("\n🔑 Avalanche na Chave (%s, %d amostras):\n", name, samples)
+
+	keyBits := 8 * keySize
+	totals := make([]int, keyBits)
+	min, max := 8*blockSize, 0
+
+	pt := make([]byte, blockSize)
+	key := make([]byte, keySize)
+	for s := 0; s < samples; s++ {
+		rand.Read(pt)
+		rand.Read(key)
+		base, err := encryptFunc(pt, key)
+		if err != nil {
+			panic(err)
+		}
+		for i := 0; i < keyBits; i++ {
+			key[i/8] ^= 1 << (i % 8)
+			c, err := encryptFunc(pt, key)
+			key[i/8] ^= 1 << (i % 8)
+			if err != nil {
+				panic(err)
+			}
+			d := bitDiff(base, c)
+			totals[i] += d
+			if d < min {
+				min = d
+			}
+			if d > max {
+				max = d
+			}
+		}
+	}
+
+	n := float64(samples * 8 * blockSize)
+	worst, worstBit := 0.0, 0
+	for w := 0; w < keyBits/32; w++ {
+		sum := 0
+		for i := w * 32; i < (w+1)*32; i++ {
+			sum += totals[i]
+		}
+		fmt.Printf("Palavra k[%d]: média %.2f bits alterados\n", w, float64(sum)/float64(32*samples))
+	}
+	for i, t := range totals {
+		if d := math.Abs(float64(t) - n/2); d > worst {
+			worst, worstBit = d, i
+		}
+	}
+	fmt.Printf("Pior bit da chave: %d → média %.2f / %d\n", worstBit, float64(totals[worstBit])/float64(samples), 8*blockSize)
+	fmt.Printf("Mínimo: %d bits, Máximo: %d bits\n", min, max)
+
+	z := worst / math.Sqrt(n/4)
+	record("Avalanche chave/"+name, -math.Expm1(float64(keyBits)*math.Log1p(-normalTwoSided(z))))
+}
+
+// ============ RELATED-KEY ============
+
+// RelatedKeyAnalysis cifra o mesmo plaintext sob K e K⊕ΔK e conta as
+// diferenças de saída.
+func RelatedKeyAnalysis(encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize int, deltaK []byte, trials int) map[[16]byte]int {
+	stats := make(map[[16]byte]int)
+	pt := make([]byte, blockSize)
+	k1 := make([]byte, keySize)
+	k2 := make([]byte, keySize)
+	for t := 0; t < trials; t++ {
+		rand.Read(pt)
+		rand.Read(k1)
+		for i := range k2 {
+			k2[i] = k1[i] ^ deltaK[i]
+		}
+		c1, err := encryptFunc(pt, k1)
+		if err != nil {
+			panic(err)
+		}
+		c2, err := encryptFunc(pt, k2)
+		if err != nil {
+			panic(err)
+		}
+
+		var d [16]byte
+		for i := 0; i < blockSize; i++ {
+			d[i] = c1[i] ^ c2[i]
+		}
+		stats[d]++
+	}
+	return stats
+}
+
+// testRelatedKey percorre as diferenças de chave e reporta a transição
+// ΔK → ΔC mais frequente, com Bonferroni sobre os ΔK testados.
+func testRelatedKey(name string, encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize int, deltas [][]byte, trials int) {
+	fmt.Printf("\n🗝  Chaves Relacionadas (%s): %d ΔK × %d amostras\n", name, len(deltas), trials)
+
+	var bestDelta []byte
+	var best DeltaCount
+	for _, dk := range deltas {
+		top := TopDeltas(RelatedKeyAnalysis(encryptFunc, blockSize, keySize, dk, trials), 1)
+		if len(top) > 0 && top[0].Count > best.Count {
+			best, bestDelta = top[0], dk
+		}
+	}
+
+	lo, hi := wilsonInterval(best.Count, trials, *alpha)
+	fmt.Printf("ΔK: %x → ΔC: %x → %d (%.5f) [%.5f, %.5f]\n", bestDelta, best.Delta[:blockSize], best.Count, float64(best.Count)/float64(trials), lo, hi)
+
+	p := differentialPValue(best.Count, trials, blockSize) * float64(len(deltas))
+	record("Chaves relacionadas/"+name, math.Min(1, p))
+}
+
+// testRelatedKeyRounds repete o experimento em Ginga reduzida para mostrar
+// a partir de quantas rodadas nenhuma transição ΔK → ΔC se destaca.
+func testRelatedKeyRounds(deltas [][]byte, trials int) {
+	fmt.Printf("\n🗝  Chaves Relacionadas por rodada (Ginga): %d ΔK × %d amostras\n", len(deltas), trials)
+
+	for r := 1; r <= ginga.Rounds; r++ {
+		best := 0
+		var bestDelta []byte
+		for _, dk := range deltas {
+			top := TopDeltas(RelatedKeyAnalysis(GingaRounds(r), 16, 32, dk, trials), 1)
+			if len(top) > 0 && top[0].Count > best {
+				best, bestDelta = top[0].Count, dk
+			}
+		}
+		p := math.Min(1, differentialPValue(best, trials, 16)*float64(len(deltas)))
+		fmt.Printf("Rodadas %2d: melhor ΔK %x → %d/%d (p = %.3g)\n", r, bestDelta, best, trials, p)
+	}
+}
+
+// ============ DIFUSÃO DA CHAVE POR RODADA ============
+
+// testKeyDiffusionRounds mede, para cada bit da chave, a primeira rodada a
+// partir da qual todos os bits do estado invertem com probabilidade
+// indistinguível de 1/2.
+func testKeyDiffusionRounds(samples int) {
+	fmt.Printf("\n⏱  Rodadas até a difusão completa de um bit da chave (Ginga, %d amostras):\n", samples)
+
+	const keyBits = 256
+	counts := make([][ginga.Rounds][128]int, keyBits)
+
+	pt := make([]byte, 16)
+	key := make([]byte, 32)
+	for s := 0; s < samples; s++ {
+		rand.Read(pt)
+		rand.Read(key)
+		k := loadKey(key)
+		var base [ginga.Rounds][4]uint32
+		st := loadState(pt)
+		for r := 0; r < ginga.Rounds; r++ {
+			st = encryptWords(st, &k, r, r+1)
+			base[r] = st
+		}
+
+		for i := 0; i < keyBits; i++ {
+			k2 := k
+			k2[i/32] ^= 1 << (i % 32)
+			st := loadState(pt)
+			for r := 0; r < ginga.Rounds; r++ {
+				st = encryptWords(st, &k2, r, r+1)
+				for w := 0; w < 4; w++ {
+					d := st[w] ^ base[r][w]
+					for d != 0 {
+						b := bits.TrailingZeros32(d)
+						counts[i][r][32*w+b]++
+						d &= d - 1
+					}
+				}
+			}
+		}
+	}
+
+	// Bonferroni sobre bits de saída, rodadas e bits da chave.
+	tol := normalQuantile(1-*alpha/(2*128*ginga.Rounds*keyBits)) * 0.5 / math.Sqrt(float64(samples))
+	needed := make([]int, keyBits)
+	for i := range counts {
+		needed[i] = ginga.Rounds + 1
+		for r := ginga.Rounds - 1; r >= 0; r-- {
+			ok := true
+			for j := 0; j < 128; j++ {
+				if math.Abs(float64(counts[i][r][j])/float64(samples)-0.5) > tol {
+					ok = false
+					break
+				}
+			}
+			if !ok {
+				break
+			}
+			needed[i] = r + 1
+		}
+	}
+
+	for w := 0; w < 8; w++ {
+		lo, hi := ginga.Rounds+1, 0
+		for i := w * 32; i < (w+1)*32; i++ {
+			if needed[i] < lo {
+				lo = needed[i]
+			}
+			if needed[i] > hi {
+				hi = needed[i]
+			}
+		}
+		fmt.Printf("Palavra k[%d]: primeiro uso na rodada %d, difusão completa em %d..%d rodadas\n", w, firstKeyUse(w)+1, lo, hi)
+	}
+
+	hist := make([]int, ginga.Rounds+2)
+	worst := 0
+	for _, n := range needed {
+		hist[n]++
+		if n > worst {
+			worst = n
+		}
+	}
+	for r, c := range hist {
+		if c > 0 {
+			label := fmt.Sprintf("%2d rodadas", r)
+			if r > ginga.Rounds {
+				label = "não difunde"
+			}
+			fmt.Printf("%s: %d bits da chave\n", label, c)
+		}
+	}
+	fmt.Printf("Pior caso: %d rodadas (margem de %d rodadas)\n", worst, ginga.Rounds-worst)
+}
+
+// firstKeyUse devolve a primeira rodada em que a palavra w da chave entra
+// em subKey32.
+func firstKeyUse(w int) int {
+	for r := 0; r < ginga.Rounds; r++ {
+		for i := 0; i < 4; i++ {
+			if (i+r)&7 == w {
+				return r
+			}
+		}
+	}
+	return -1
+}
+
+// ============ CHAVES FRACAS / EQUIVALENTES ============
+
+// weakKeyClass descreve uma família de chaves estruturadas suspeitas.
+type weakKeyClass struct {
+	name string
+	gen  func() []byte
+}
+
+func wordsKey(w [8]uint32) []byte {
+	key := make([]byte, 32)
+	for i, v := range w {
+		binary.LittleEndian.PutUint32(key[i*4:], v)
+	}
+	return key
+}
+
+func randomWord() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return binary.LittleEndian.Uint32(b[:])
+}
+
+var weakKeyClasses = []weakKeyClass{
+	{"zero", func() []byte { return make([]byte, 32) }},
+	{"uns", func() []byte { return bytes.Repeat([]byte{0xFF}, 32) }},
+	{"0xA5A5A5A5", func() []byte { return bytes.Repeat([]byte{0xA5}, 32) }},
+	{"0x5A5A5A5A", func() []byte { return bytes.Repeat([]byte{0x5A}, 32) }},
+	{"0x3C3C3C3C", func() []byte { return bytes.Repeat([]byte{0x3C}, 32) }},
+	{"palavras iguais", func() []byte {
+		w := randomWord()
+		return wordsKey([8]uint32{w, w, w, w, w, w, w, w})
+	}},
+	{"byte repetido", func() []byte {
+		var b [1]byte
+		rand.Read(b[:])
+		return bytes.Repeat(b[:], 32)
+	}},
+	{"período 2", func() []byte {
+		a, b := randomWord(), randomWord()
+		return wordsKey([8]uint32{a, b, a, b, a, b, a, b})
+	}},
+	{"período 4", func() []byte {
+		a, b, c, d := randomWord(), randomWord(), randomWord(), randomWord()
+		return wordsKey([8]uint32{a, b, c, d, a, b, c, d})
+	}},
+	{"palavras rotacionadas", func() []byte {
+		w := randomWord()
+		var k [8]uint32
+		for i := range k {
+			k[i] = bits.RotateLeft32(w, i)
+		}
+		return wordsKey(k)
+	}},
+}
+
+// testWeakKeys procura chaves equivalentes e classes de chaves fracas em
+// Ginga. Cada classe é testada quanto a pontos fixos, involuções e
+// uniformidade da saída para plaintexts de baixa entropia (contador).
+func testWeakKeys(keysPerClass, blocks int) {
+	fmt.Println("\n🧷 Chaves Fracas e Equivalentes (Ginga):")
+
+	// Chaves equivalentes estruturais: uma palavra nunca usada pelo
+	// escalonamento tornaria K e K⊕(palavra) equivalentes.
+	unused := 0
+	for w := 0; w < 8; w++ {
+		if firstKeyUse(w) < 0 {
+			fmt.Printf("⚠️  Palavra k[%d] nunca é usada: chaves equivalentes!\n", w)
+			unused++
+		}
+	}
+	if unused == 0 {
+		fmt.Println("Todas as 8 palavras da chave são usadas; subKey32 é injetiva em k[(i+r)&7].")
+	}
+
+	// Busca empírica de chaves equivalentes para ΔK de um bit.
+	collisions := 0
+	probes := 0
+	pt := make([]byte, 16)
+	for _, dk := range SingleBitDeltas(32) {
+		key := randomBytes(32)
+		rand.Read(pt)
+		k2 := xorBytes(key, dk)
+		c1, _ := ginga.Encrypt(pt, key)
+		c2, _ := ginga.Encrypt(pt, k2)
+		probes++
+		if bytes.Equal(c1, c2) {
+			collisions++
+		}
+	}
+	fmt.Printf("Colisões E_K(P) = E_K'(P) para ΔK de 1 bit: %d / %d\n", collisions, probes)
+	record("Chaves equivalentes/Ginga", binomialSF(collisions, probes, math.Pow(2, -128)))
+
+	tests := float64(len(weakKeyClasses) * keysPerClass)
+	for _, class := range weakKeyClasses {
+		worstP := 1.0
+		fixed, invol := 0, 0
+		for n := 0; n < keysPerClass; n++ {
+			key := class.gen()
+			block, err := ginga.NewCipher(key)
+			if err != nil {
+				panic(err)
+			}
+
+			byteCounts := make([]int, 256)
+			x := make([]byte, 16)
+			y := make([]byte, 16)
+			z := make([]byte, 16)
+			for i := 0; i < blocks; i++ {
+				binary.BigEndian.PutUint64(x[8:], uint64(i))
+				block.Encrypt(y, x)
+				for _, b := range y {
+					byteCounts[b]++
+				}
+				if bytes.Equal(x, y) {
+					fixed++
+				}
+				block.Encrypt(z, y)
+				if bytes.Equal(z, x) {
+					invol++
+				}
+			}
+
+			expected := float64(16*blocks) / 256
+			chi := 0.0
+			for _, o := range byteCounts {
+				d := float64(o) - expected
+				chi += d * d / expected
+			}
+			worstP = math.Min(worstP, chiSquaredSF(chi, 255))
+		}
+
+		flag := ""
+		if worstP*tests < *alpha || fixed > 0 || invol > 0 {
+			flag = " ⚠️  suspeita"
+		}
+		fmt.Printf("%-22s pior p(χ²) = %-10.4g pontos fixos: %d, involuções: %d%s\n", class.name, worstP, fixed, invol, flag)
+		record("Chave fraca/"+class.name, math.Min(1, worstP*float64(keysPerClass)))
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ KEY WRAP (KW / KWP) ============
+
+// keyWrapVector: chave de embrulho, chave embrulhada, resultado e se usa
+// preenchimento (KWP).
+type keyWrapVector struct {
+	kek, key, out string
+	padded        bool
+}
+
+// Vetores do RFC 3394 (seção 4.1) e do RFC 5649 (seção 6), com AES.
+var keyWrapAESVectors = []keyWrapVector{
+	{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5", false},
+	{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a", true},
+	{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f", true},
+}
+
+// Vetores Ginga com chave de embrulho 000102...1F.
+var keyWrapGingaVectors = []keyWrapVector{
+	{"", "00112233445566778899AABBCCDDEEFF", "7c8f227d152cf6d731f772f71dc4c08bcba925106fcc565f", false},
+	{"", "00112233445566778899AABBCCDDEEFF0001020304050607", "2096c71df54a695314e1234b9bb41b49e7f077ffc711838ab11a3019a3b2e0de", false},
+	{"", "c37b7e6492584340bed12207808941155068f738", "43cdc82515f0112e0150b6ac9c176c6404f7a842942ecdf81ed9bd099a07e6ec", true},
+	{"", "466f7250617369", "a9f2dcad67d2e93285f0c292e879beca", true},
+	{"", "00112233445566778899AABBCCDDEEFF", "681d9fc082a6dee8b794d817753a4a266391ba2b5c6202da", true},
+}
+
+func wrapFuncs(padded bool) (func(cipher.Block, []byte) ([]byte, error), func(cipher.Block, []byte) ([]byte, error)) {
+	if padded {
+		return ginga.WrapKeyWithPadding, ginga.UnwrapKeyWithPadding
+	}
+	return ginga.WrapKey, ginga.UnwrapKey
+}
+
+// isIntegrityError informa se err é o erro tipado de falha de verificação.
+func isIntegrityError(err error) bool {
+	var ie *ginga.IntegrityError
+	return errors.As(err, &ie)
+}
+
+func testKeyWrap() {
+	fmt.Println("\n🗝️  Embrulho de chaves: KW (RFC 3394) e KWP (RFC 5649)")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+
+	gingaKEK, _ := ginga.NewCipher(seq(32))
+	check := func(name string, b cipher.Block, v keyWrapVector) {
+		wrap, unwrap := wrapFuncs(v.padded)
+		got, err := wrap(b, mustHex(v.key))
+		if err != nil || !bytes.Equal(got, mustHex(v.out)) {
+			fail("%s: esperado %s, obtido %x (%v)", name, v.out, got, err)
+			return
+		}
+		if key, err := unwrap(b, got); err != nil || !bytes.Equal(key, mustHex(v.key)) {
+			fail("%s: desembrulho não recupera a chave (%v)", name, err)
+		}
+	}
+	for i, v := range keyWrapAESVectors {
+		b, _ := aes.NewCipher(mustHex(v.kek))
+		check(fmt.Sprintf("AES vetor %d", i+1), b, v)
+	}
+	for i, v := range keyWrapGingaVectors {
+		check(fmt.Sprintf("Ginga vetor %d", i+1), gingaKEK, v)
+	}
+
+	// KWP aceita qualquer tamanho; KW só múltiplos de 8 a partir de 16.
+	otherKEK, _ := ginga.NewCipher(randomBytes(32))
+	for _, padded := range []bool{false, true} {
+		wrap, unwrap := wrapFuncs(padded)
+		mode := map[bool]string{false: "KW", true: "KWP"}[padded]
+		for n := 1; n <= 72; n++ {
+			key := randomBytes(n)
+			w, err := wrap(gingaKEK, key)
+			if !padded && (n < 16 || n%8 != 0) {
+				if err == nil {
+					fail("%s aceitou chave de %d bytes", mode, n)
+				}
+				continue
+			}
+			if err != nil || len(w) != (n+7)&^7+8 {
+				fail("%s: chave de %d bytes: %v", mode, n, err)
+				continue
+			}
+			if got, err := unwrap(gingaKEK, w); err != nil || !bytes.Equal(got, key) {
+				fail("%s: chave de %d bytes não volta (%v)", mode, n, err)
+			}
+
+			// Qualquer bit trocado e a chave de embrulho errada dão
+			// IntegrityError, sem devolver bytes.
+			for i := 0; i < len(w); i += 7 {
+				bad := bytes.Clone(w)
+				bad[i] ^= 0x10
+				if got, err := unwrap(gingaKEK, bad); !isIntegrityError(err) || got != nil {
+					fail("%s: chave de %d bytes, byte %d adulterado: %v", mode, n, i, err)
+				}
+			}
+			if _, err := unwrap(otherKEK, w); !isIntegrityError(err) {
+				fail("%s: chave de %d bytes aceita com a chave de embrulho errada", mode, n)
+			}
+		}
+		// Tamanhos impossíveis são erro de uso, não de integridade.
+		for _, n := range []int{0, 8, 15, 17} {
+			if _, err := unwrap(gingaKEK, make([]byte, n)); err == nil || isIntegrityError(err) {
+				fail("%s: entrada de %d bytes: %v", mode, n, err)
+			}
+		}
+	}
+
+	// Um KW válido não é um KWP válido: os valores iniciais diferem.
+	w, _ := ginga.WrapKey(gingaKEK, seq(16))
+	if _, err := ginga.UnwrapKeyWithPadding(gingaKEK, w); !isIntegrityError(err) {
+		fail("KWP aceitou um embrulho KW")
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ Vetores AES (RFC 3394 e 5649) e Ginga conferem; adulterações detectadas com IntegrityError")
+	} else {
+		fmt.Printf("❌ %d verificações de embrulho de chaves falharam\n", failures)
+	}
+}
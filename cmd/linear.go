@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// ============ BUSCA DE TRILHAS LINEARES ============
+//
+// Modelo linear de uma rodada de Ginga, palavra a palavra, com máscaras:
+//
+//	x + k          adição de duas variáveis; a máscara da subchave é livre
+//	               e a correlação é exata (Wallén): ±2^-hw(z),
+//	               z = M^T(u ⊕ v ⊕ w), com u ⊕ v ≼ z e w ⊕ v ≼ z
+//	x ^ 0xA5A5A5A5 XOR com constante (só altera o sinal)
+//	x + 0x3C3C3C3C adição com constante (correlação exata por cadeia de carries)
+//	rotações, ^k   lineares (a máscara acompanha a rotação)
+//
+// e mixState32, cuja propagação de máscaras é a inversa transposta. O peso de
+// uma trilha é -log2 |correlação|, usando o mesmo motor de trails.go.
+
+// enumAddKeyLinear enumera, do bit mais significativo para o menos, as
+// aproximações u → v da adição com subchave de peso <= budget. Se free, u
+// também é escolhido (primeira rodada) e a enumeração para quando emit
+// devolve false. A máscara da chave é implícita: o
+// estado guarda o menor hw(z_31..z_i) para z_i = 0 e z_i = 1, de modo que
+// cada par (u, v) sai uma única vez com a melhor máscara de chave.
+func enumAddKeyLinear(u uint32, free bool, budget float64, emit func(u, v uint32, w int) bool) bool {
+	const inf = 1 << 30
+	var rec func(i int, c0, c1 int, a, v uint32) bool
+	rec = func(i int, c0, c1 int, a, v uint32) bool {
+		for ui := uint32(0); ui < 2; ui++ {
+			if !free && ui != (u>>i)&1 {
+				continue
+			}
+			for vi := uint32(0); vi < 2; vi++ {
+				// z_i = 0 exige u_i = v_i (e k_i = v_i); então z_{i-1} = u_i.
+				// z_i = 1 deixa z_{i-1} livre pela escolha de k_i.
+				n0, n1 := inf, inf
+				if c0 < inf && ui == vi {
+					if ui == 0 {
+						n0 = c0
+					} else {
+						n1 = c0 + 1
+					}
+				}
+				if c1 < inf {
+					n0 = min(n0, c1)
+					n1 = min(n1, c1+1)
+				}
+				if i == 0 {
+					w := c1
+					if ui == vi {
+						w = min(w, c0)
+					}
+					if w < inf && float64(w) <= budget+weightEps && !emit(a|ui, v|vi, w) {
+						return false
+					}
+					continue
+				}
+				if float64(min(n0, n1)) > budget+weightEps {
+					continue
+				}
+				if !rec(i-1, n0, n1, a|ui<<i, v|vi<<i) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return rec(31, 0, inf, 0, 0)
+}
+
+// addConstLinearStep avança as massas com sinal da cadeia de carries de
+// x + c para o bit j com máscaras de entrada uj e de saída vj.
+func addConstLinearStep(mass [2]float64, cj, uj, vj uint32) [2]float64 {
+	var next [2]float64
+	for cr := uint32(0); cr < 2; cr++ {
+		m := mass[cr]
+		if m == 0 {
+			continue
+		}
+		for x := uint32(0); x < 2; x++ {
+			y := x ^ cj ^ cr
+			nc := (x & cj) | (x & cr) | (cj & cr)
+			if (uj&x)^(vj&y) == 1 {
+				next[nc] -= m / 2
+			} else {
+				next[nc] += m / 2
+			}
+		}
+	}
+	return next
+}
+
+// enumAddConstLinear enumera as aproximações u → v de x ↦ x + c com peso
+// <= budget. A norma L1 das massas nunca cresce, logo limita a correlação.
+func enumAddConstLinear(u, c uint32, budget float64, emit func(v uint32, w float64) bool) bool {
+	var rec func(j int, v uint32, mass [2]float64) bool
+	rec = func(j int, v uint32, mass [2]float64) bool {
+		uj, cj := (u>>j)&1, (c>>j)&1
+		for vj := uint32(0); vj < 2; vj++ {
+			m := addConstLinearStep(mass, cj, uj, vj)
+			if j == 31 {
+				cor := math.Abs(m[0] + m[1])
+				if cor == 0 {
+					continue
+				}
+				if w := -math.Log2(cor); w <= budget+weightEps && !emit(v|vj<<31, w) {
+					return false
+				}
+				continue
+			}
+			l1 := math.Abs(m[0]) + math.Abs(m[1])
+			if l1 == 0 || -math.Log2(l1) > budget+weightEps {
+				continue
+			}
+			if !rec(j+1, v|vj<<j, m) {
+				return false
+			}
+		}
+		return true
+	}
+	return rec(0, 0, [2]float64{1, 0})
+}
+
+// linearMask propaga as máscaras pela parte linear da rodada r.
+func linearMask(m [4]uint32, r int) [4]uint32 {
+	for i := range m {
+		m[i] = gRotl(m[i], 7+((r+3)&31)+((r+5)&31))
+	}
+	m[1] ^= gRotr(m[0], 5)
+	m[2] ^= gRotr(m[1], 11)
+	m[3] ^= gRotr(m[2], 17)
+	m[0] ^= gRotr(m[3], 23)
+	return m
+}
+
+// linModel é o modelo linear descrito acima.
+var linModel = &trailModel{
+	enumWord: func(alpha uint32, free bool, budget float64, emit func(a, g, d uint32, w float64) bool) bool {
+		return enumAddKeyLinear(alpha, free, budget, func(u, v uint32, wk int) bool {
+			return enumAddConstLinear(v, confuseConst, budget-float64(wk), func(d uint32, wc float64) bool {
+				return emit(u, v, d, float64(wk)+wc)
+			})
+		})
+	},
+	linear: linearMask,
+}
+
+func maskParity(m, x [4]uint32) uint32 {
+	p := 0
+	for i := range m {
+		p += bits.OnesCount32(m[i] & x[i])
+	}
+	return uint32(p & 1)
+}
+
+// validateLinearTrail mede a correlação da aproximação (máscara de entrada
+// → máscara de saída da trilha) em Ginga reduzida para várias chaves.
+func validateLinearTrail(t *Trail, keys, samples int) {
+	in := t.Rounds[0].In
+	out := t.Rounds[len(t.Rounds)-1].Out
+	sumAbs, sumSq := 0.0, 0.0
+	for n := 0; n < keys; n++ {
+		k := loadKey(randomBytes(32))
+		agree := 0
+		for s := 0; s < samples; s++ {
+			x := loadState(randomBytes(16))
+			y := encryptWords(x, &k, t.Start, t.Start+len(t.Rounds))
+			if maskParity(in, x) == maskParity(out, y) {
+				agree++
+			}
+		}
+		cor := 2*float64(agree)/float64(samples) - 1
+		sumAbs += math.Abs(cor)
+		sumSq += cor * cor
+	}
+	noise := 1 / math.Sqrt(float64(samples))
+	fmt.Printf("  Empírico (%d chaves): |c| médio = 2^%.2f, √ELP = 2^%.2f, ruído ≈ 2^%.2f (trilha: 2^-%.2f)\n",
+		keys, math.Log2(sumAbs/float64(keys)), 0.5*math.Log2(sumSq/float64(keys)), math.Log2(noise), t.Weight)
+}
+
+func testLinearTrails() {
+	fmt.Printf("\n📐 Busca de trilhas lineares (Ginga, até %d rodadas, %v por busca):\n", *trailRounds, *trailBudget)
+
+	trails, lbs := BestTrails(linModel, *trailRounds, *trailBudget)
+	for k := 1; k < len(trails); k++ {
+		t := trails[k]
+		if t == nil {
+			fmt.Printf("Rodadas %2d: nenhuma trilha no prazo, peso >= %.2f\n", k, lbs[k])
+			continue
+		}
+		status := "ótima"
+		if t.Weight > lbs[k]+weightEps {
+			status = fmt.Sprintf("limite inferior %.2f", lbs[k])
+		}
+		fmt.Printf("Rodadas %2d: peso %.2f (|c| = 2^-%.2f, %s)\n", k, t.Weight, t.Weight, status)
+		printTrail(t)
+		if t.Weight <= 8 {
+			validateLinearTrail(t, 4, 1<<20)
+		}
+	}
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/pedroalbanese/ginga"
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// ============ NIST SP 800-22 ============
+//
+// Implementação nativa da bateria do SP 800-22 rev. 1a, com os parâmetros
+// recomendados para sequências de 2^20 bits. Cada teste devolve um ou mais
+// p-valores; a bateria é aplicada a várias sequências e o relatório segue o
+// formato do finalAnalysisReport: histograma C1..C10 dos p-valores, p-valor
+// da uniformidade (χ², 9 g.l.) e proporção de sequências aprovadas.
+
+var (
+	nistSeqs = flag.Int("nist-seqs", 55, "número de sequências por gerador na bateria SP 800-22")
+	nistBits = flag.Int("nist-bits", 1<<20, "bits por sequência na bateria SP 800-22")
+)
+
+const nistAlpha = 0.01
+
+// nistTest é um teste da bateria; run devolve nil quando a sequência não
+// satisfaz as condições do teste (excursões aleatórias com poucos ciclos).
+type nistTest struct {
+	name string
+	run  func(e []uint8) []float64
+}
+
+var nistTests = []nistTest{
+	{"Frequency", nistFrequency},
+	{"BlockFrequency", func(e []uint8) []float64 { return nistBlockFrequency(e, 128) }},
+	{"CumulativeSums", nistCumulativeSums},
+	{"Runs", nistRuns},
+	{"LongestRun", nistLongestRun},
+	{"Rank", nistRank},
+	{"FFT", nistDFT},
+	{"ApproximateEntropy", func(e []uint8) []float64 { return nistApproximateEntropy(e, 10) }},
+	{"RandomExcursions", nistRandomExcursions},
+	{"Serial", func(e []uint8) []float64 { return nistSerial(e, 16) }},
+	{"LinearComplexity", func(e []uint8) []float64 { return nistLinearComplexity(e, 500) }},
+}
+
+// bytesToBits expande os bytes em bits, do mais significativo para o menos.
+func bytesToBits(b []byte, n int) []uint8 {
+	e := make([]uint8, n)
+	for i := range e {
+		e[i] = b[i/8] >> (7 - i%8) & 1
+	}
+	return e
+}
+
+func nistFrequency(e []uint8) []float64 {
+	s := 0
+	for _, b := range e {
+		s += 2*int(b) - 1
+	}
+	sObs := math.Abs(float64(s)) / math.Sqrt(float64(len(e)))
+	return []float64{math.Erfc(sObs / math.Sqrt2)}
+}
+
+func nistBlockFrequency(e []uint8, m int) []float64 {
+	n := len(e) / m
+	chi := 0.0
+	for i := 0; i < n; i++ {
+		ones := 0
+		for _, b := range e[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		d := float64(ones)/float64(m) - 0.5
+		chi += d * d
+	}
+	chi *= 4 * float64(m)
+	return []float64{igamc(float64(n)/2, chi/2)}
+}
+
+func nistRuns(e []uint8) []float64 {
+	n := float64(len(e))
+	ones := 0
+	for _, b := range e {
+		ones += int(b)
+	}
+	pi := float64(ones) / n
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(n) {
+		return []float64{0}
+	}
+	v := 1
+	for i := 1; i < len(e); i++ {
+		if e[i] != e[i-1] {
+			v++
+		}
+	}
+	num := math.Abs(float64(v) - 2*n*pi*(1-pi))
+	return []float64{math.Erfc(num / (2 * math.Sqrt(2*n) * pi * (1 - pi)))}
+}
+
+// nistLongestRun usa M = 10000 (n ≥ 750000) ou M = 128.
+func nistLongestRun(e []uint8) []float64 {
+	m, lo := 128, 4
+	pi := []float64{0.1174, 0.2430, 0.2493, 0.1752, 0.1027, 0.1124}
+	if len(e) >= 750000 {
+		m, lo = 10000, 10
+		pi = []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}
+	}
+	k := len(pi) - 1
+	n := len(e) / m
+	nu := make([]int, len(pi))
+	for i := 0; i < n; i++ {
+		longest, run := 0, 0
+		for _, b := range e[i*m : (i+1)*m] {
+			if b == 1 {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+		nu[min(max(longest-lo, 0), k)]++
+	}
+	chi := 0.0
+	for i, p := range pi {
+		exp := float64(n) * p
+		chi += (float64(nu[i]) - exp) * (float64(nu[i]) - exp) / exp
+	}
+	return []float64{igamc(float64(k)/2, chi/2)}
+}
+
+// gf2Rank calcula o posto de uma matriz 32×32 sobre GF(2).
+func gf2Rank(rows [32]uint32) int {
+	rank := 0
+	for col := 31; col >= 0 && rank < 32; col-- {
+		pivot := -1
+		for r := rank; r < 32; r++ {
+			if rows[r]>>col&1 == 1 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[rank], rows[pivot] = rows[pivot], rows[rank]
+		for r := 0; r < 32; r++ {
+			if r != rank && rows[r]>>col&1 == 1 {
+				rows[r] ^= rows[rank]
+			}
+		}
+		rank++
+	}
+	return rank
+}
+
+func nistRank(e []uint8) []float64 {
+	n := len(e) / 1024
+	var full, minus1 int
+	for i := 0; i < n; i++ {
+		var rows [32]uint32
+		for r := range rows {
+			for c := 0; c < 32; c++ {
+				rows[r] = rows[r]<<1 | uint32(e[i*1024+r*32+c])
+			}
+		}
+		switch gf2Rank(rows) {
+		case 32:
+			full++
+		case 31:
+			minus1++
+		}
+	}
+	nf := float64(n)
+	rest := n - full - minus1
+	chi := math.Pow(float64(full)-0.2888*nf, 2)/(0.2888*nf) +
+		math.Pow(float64(minus1)-0.5776*nf, 2)/(0.5776*nf) +
+		math.Pow(float64(rest)-0.1336*nf, 2)/(0.1336*nf)
+	return []float64{math.Exp(-chi / 2)}
+}
+
+// fft calcula a DFT de x (comprimento potência de 2) no próprio vetor.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*wk
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+}
+
+// nistDFT trunca a sequência na maior potência de 2.
+func nistDFT(e []uint8) []float64 {
+	n := 1 << (bits.Len(uint(len(e))) - 1)
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(2*float64(e[i])-1, 0)
+	}
+	fft(x)
+	t := math.Sqrt(math.Log(1/0.05) * float64(n))
+	n0 := 0.95 * float64(n) / 2
+	n1 := 0
+	for _, v := range x[:n/2] {
+		if cmplx.Abs(v) < t {
+			n1++
+		}
+	}
+	d := (float64(n1) - n0) / math.Sqrt(float64(n)*0.95*0.05/4)
+	return []float64{math.Erfc(math.Abs(d) / math.Sqrt2)}
+}
+
+// psiSquared calcula ψ²_m com blocos sobrepostos e volta circular.
+func psiSquared(e []uint8, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+	n := len(e)
+	counts := make([]int, 1<<m)
+	w := 0
+	for i := 0; i < m-1; i++ {
+		w = w<<1 | int(e[i])
+	}
+	mask := 1<<m - 1
+	for i := 0; i < n; i++ {
+		w = (w<<1 | int(e[(i+m-1)%n])) & mask
+		counts[w]++
+	}
+	sum := 0.0
+	for _, c := range counts {
+		sum += float64(c) * float64(c)
+	}
+	return sum*float64(int(1)<<m)/float64(n) - float64(n)
+}
+
+func nistSerial(e []uint8, m int) []float64 {
+	p0, p1, p2 := psiSquared(e, m), psiSquared(e, m-1), psiSquared(e, m-2)
+	d1 := p0 - p1
+	d2 := p0 - 2*p1 + p2
+	return []float64{
+		igamc(math.Pow(2, float64(m-2)), d1/2),
+		igamc(math.Pow(2, float64(m-3)), d2/2),
+	}
+}
+
+// apEnPhi calcula φ(m) = Σ π_i ln π_i sobre blocos sobrepostos circulares.
+func apEnPhi(e []uint8, m int) float64 {
+	if m == 0 {
+		return 0
+	}
+	n := len(e)
+	counts := make([]int, 1<<m)
+	w := 0
+	for i := 0; i < m-1; i++ {
+		w = w<<1 | int(e[i])
+	}
+	mask := 1<<m - 1
+	for i := 0; i < n; i++ {
+		w = (w<<1 | int(e[(i+m-1)%n])) & mask
+		counts[w]++
+	}
+	phi := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(n)
+			phi += p * math.Log(p)
+		}
+	}
+	return phi
+}
+
+func nistApproximateEntropy(e []uint8, m int) []float64 {
+	apEn := apEnPhi(e, m) - apEnPhi(e, m+1)
+	chi := 2 * float64(len(e)) * (math.Ln2 - apEn)
+	return []float64{igamc(math.Pow(2, float64(m-1)), chi/2)}
+}
+
+// cusumP é o p-valor das somas acumuladas para o desvio máximo z.
+func cusumP(n, z int) float64 {
+	nf, zf := float64(n), float64(z)
+	phi := func(x float64) float64 { return 1 - normalSF(x) }
+	sum1 := 0.0
+	for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+		sum1 += phi(float64(4*k+1)*zf/math.Sqrt(nf)) - phi(float64(4*k-1)*zf/math.Sqrt(nf))
+	}
+	sum2 := 0.0
+	for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+		sum2 += phi(float64(4*k+3)*zf/math.Sqrt(nf)) - phi(float64(4*k+1)*zf/math.Sqrt(nf))
+	}
+	return 1 - sum1 + sum2
+}
+
+func nistCumulativeSums(e []uint8) []float64 {
+	fwd, s := 0, 0
+	for _, b := range e {
+		s += 2*int(b) - 1
+		fwd = max(fwd, abs(s))
+	}
+	bwd := 0
+	s = 0
+	for i := len(e) - 1; i >= 0; i-- {
+		s += 2*int(e[i]) - 1
+		bwd = max(bwd, abs(s))
+	}
+	return []float64{cusumP(len(e), max(fwd, 1)), cusumP(len(e), max(bwd, 1))}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// nistRandomExcursions devolve os 8 p-valores dos estados ±1..±4, ou nil se
+// houver menos de 500 ciclos.
+func nistRandomExcursions(e []uint8) []float64 {
+	states := []int{-4, -3, -2, -1, 1, 2, 3, 4}
+	var nu [8][6]int
+	var visits [8]int
+	cycles := 0
+	s := 0
+	closeCycle := func() {
+		for i := range visits {
+			nu[i][min(visits[i], 5)]++
+			visits[i] = 0
+		}
+		cycles++
+	}
+	for _, b := range e {
+		s += 2*int(b) - 1
+		if s == 0 {
+			closeCycle()
+		} else if s >= -4 && s <= 4 {
+			idx := s + 4
+			if s > 0 {
+				idx--
+			}
+			visits[idx]++
+		}
+	}
+	if s != 0 {
+		closeCycle()
+	}
+	if cycles < 500 {
+		return nil
+	}
+
+	p := make([]float64, len(states))
+	for i, x := range states {
+		ax := math.Abs(float64(x))
+		q := 1 - 1/(2*ax)
+		pi := [6]float64{
+			q,
+			1 / (4 * ax * ax),
+			1 / (4 * ax * ax) * q,
+			1 / (4 * ax * ax) * q * q,
+			1 / (4 * ax * ax) * q * q * q,
+			1 / (2 * ax) * q * q * q * q,
+		}
+		chi := 0.0
+		for k, pk := range pi {
+			exp := float64(cycles) * pk
+			chi += (float64(nu[i][k]) - exp) * (float64(nu[i][k]) - exp) / exp
+		}
+		p[i] = igamc(2.5, chi/2)
+	}
+	return p
+}
+
+// berlekampMassey devolve a complexidade linear da sequência.
+func berlekampMassey(s []uint8) int {
+	n := len(s)
+	c := make([]uint8, n+1)
+	b := make([]uint8, n+1)
+	t := make([]uint8, n+1)
+	c[0], b[0] = 1, 1
+	l, m := 0, -1
+	for i := 0; i < n; i++ {
+		d := s[i]
+		for j := 1; j <= l; j++ {
+			d ^= c[j] & s[i-j]
+		}
+		if d == 0 {
+			continue
+		}
+		copy(t, c)
+		for j := 0; j+i-m <= n; j++ {
+			c[j+i-m] ^= b[j]
+		}
+		if 2*l <= i {
+			l, m = i+1-l, i
+			copy(b, t)
+		}
+	}
+	return l
+}
+
+func nistLinearComplexity(e []uint8, m int) []float64 {
+	pi := []float64{0.010417, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
+	n := len(e) / m
+	mf := float64(m)
+	sign := 1.0
+	if m%2 == 1 {
+		sign = -1
+	}
+	mu := mf/2 + (9-sign)/36 - (mf/3+2.0/9)/math.Pow(2, mf)
+	var nu [7]int
+	for i := 0; i < n; i++ {
+		l := float64(berlekampMassey(e[i*m : (i+1)*m]))
+		t := sign*(l-mu) + 2.0/9
+		switch {
+		case t <= -2.5:
+			nu[0]++
+		case t <= -1.5:
+			nu[1]++
+		case t <= -0.5:
+			nu[2]++
+		case t <= 0.5:
+			nu[3]++
+		case t <= 1.5:
+			nu[4]++
+		case t <= 2.5:
+			nu[5]++
+		default:
+			nu[6]++
+		}
+	}
+	chi := 0.0
+	for i, p := range pi {
+		exp := float64(n) * p
+		chi += (float64(nu[i]) - exp) * (float64(nu[i]) - exp) / exp
+	}
+	return []float64{igamc(3, chi/2)}
+}
+
+// ============ GERADORES ============
+
+// ctrStream devolve um gerador de bytes do bloco em modo CTR.
+func ctrStream(block cipher.Block) func([]byte) {
+	stream := cipher.NewCTR(block, randomBytes(block.BlockSize()))
+	return func(b []byte) {
+		clear(b)
+		stream.XORKeyStream(b, b)
+	}
+}
+
+// hashCounterStream gera H(semente || contador) para contadores sucessivos.
+func hashCounterStream() func([]byte) {
+	seed := randomBytes(32)
+	var ctr uint64
+	return func(b []byte) {
+		for off := 0; off < len(b); off += gingahash.DigestSize {
+			h := gingahash.New()
+			h.Write(seed)
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], ctr)
+			h.Write(c[:])
+			ctr++
+			copy(b[off:], h.Sum(nil))
+		}
+	}
+}
+
+// ============ RELATÓRIO ============
+
+// nistRow acumula os p-valores de uma linha do relatório.
+type nistRow struct {
+	name string
+	ps   []float64
+}
+
+func runNIST(name string, gen func([]byte), seqs, nbits int) {
+	fmt.Printf("\n📊 NIST SP 800-22 (%s, %d sequências de %d bits):\n", name, seqs, nbits)
+
+	var rows []*nistRow
+	index := map[string]*nistRow{}
+	buf := make([]byte, (nbits+7)/8)
+	for s := 0; s < seqs; s++ {
+		gen(buf)
+		e := bytesToBits(buf, nbits)
+		for _, t := range nistTests {
+			for i, p := range t.run(e) {
+				key := t.name
+				if i > 0 || t.name == "CumulativeSums" || t.name == "Serial" || t.name == "RandomExcursions" {
+					key = fmt.Sprintf("%s #%d", t.name, i+1)
+				}
+				row := index[key]
+				if row == nil {
+					row = &nistRow{name: key}
+					index[key] = row
+					rows = append(rows, row)
+				}
+				row.ps = append(row.ps, p)
+			}
+		}
+	}
+
+	fmt.Println(" C1  C2  C3  C4  C5  C6  C7  C8  C9 C10  P-VALOR   PROPORÇÃO  TESTE")
+	for _, row := range rows {
+		var bins [10]int
+		passed := 0
+		for _, p := range row.ps {
+			bins[min(int(p*10), 9)]++
+			if p >= nistAlpha {
+				passed++
+			}
+		}
+		m := len(row.ps)
+		exp := float64(m) / 10
+		chi := 0.0
+		for _, c := range bins {
+			chi += (float64(c) - exp) * (float64(c) - exp) / exp
+		}
+		uniformity := igamc(4.5, chi/2)
+
+		// Intervalo de aceitação da proporção: p̂ ± 3·√(p̂(1-p̂)/m).
+		ph := 1 - nistAlpha
+		minPass := ph - 3*math.Sqrt(ph*(1-ph)/float64(m))
+		mark := ""
+		if float64(passed)/float64(m) < minPass || uniformity < 0.0001 {
+			mark = " *"
+		}
+		for _, c := range bins {
+			fmt.Printf("%3d ", c)
+		}
+		fmt.Printf(" %.6f  %4d/%-4d  %s%s\n", uniformity, passed, m, row.name, mark)
+
+		record(fmt.Sprintf("NIST/%s/%s", name, row.name), binomialSF(m-passed, m, nistAlpha))
+	}
+	fmt.Printf("Proporção mínima (α = %.2f): %.4f para %d sequências\n", nistAlpha,
+		1-nistAlpha-3*math.Sqrt(nistAlpha*(1-nistAlpha)/float64(seqs)), seqs)
+}
+
+func testNIST() {
+	gingaBlock, err := ginga.NewCipher(randomBytes(32))
+	if err != nil {
+		panic(err)
+	}
+	aesBlock, err := aes.NewCipher(randomBytes(32))
+	if err != nil {
+		panic(err)
+	}
+	runNIST("Ginga-CTR", ctrStream(gingaBlock), *nistSeqs, *nistBits)
+	runNIST("GingaHash-contador", hashCounterStream(), *nistSeqs, *nistBits)
+	runNIST("AES-CTR", ctrStream(aesBlock), *nistSeqs, *nistBits)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
+
+	"github.com/pedroalbanese/ginga/noise"
+)
+
+// ============ NOISE (XX / IK) ============
+
+// aesGCM e sha256Func são AESGCM e SHA256 da especificação (seções 12.2 e
+// 12.3), só para conferir o pacote contra vetores de outras implementações.
+type aesGCM struct{}
+
+func (aesGCM) Cipher(k [32]byte) cipher.AEAD {
+	b, err := aes.NewCipher(k[:])
+	if err != nil {
+		panic(err)
+	}
+	a, err := cipher.NewGCM(b)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Nonce: 32 bits zero e o contador em 64 bits big-endian.
+func (aesGCM) Nonce(n uint64) []byte {
+	var nonce [12]byte
+	binary.BigEndian.PutUint64(nonce[4:], n)
+	return nonce[:]
+}
+
+func (aesGCM) CipherName() string { return "AESGCM" }
+
+type sha256Func struct{}
+
+func (sha256Func) Hash() hash.Hash  { return sha256.New() }
+func (sha256Func) HashLen() int     { return sha256.Size }
+func (sha256Func) BlockLen() int    { return sha256.BlockSize }
+func (sha256Func) HashName() string { return "SHA256" }
+
+// noiseVector é um vetor do formato da cacophony: chaves fixas, as
+// mensagens do handshake alternadas a partir do iniciador e depois duas de
+// transporte (sem dado associado), do iniciador e do respondedor.
+type noiseVector struct {
+	pattern                      noise.Pattern
+	initStatic, respStatic       string
+	initEphemeral, respEphemeral string
+	prologue                     string
+	payloads, ciphertexts        []string
+}
+
+// Vetores de Noise_{XX,IK}_25519_AESGCM_SHA256 com prólogo, da coleção da
+// cacophony (também em flynn/noise, vectors.txt).
+var noiseVectors = []noiseVector{
+	{
+		pattern:       noise.PatternXX,
+		initStatic:    "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		respStatic:    "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		initEphemeral: "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+		respEphemeral: "4142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60",
+		prologue:      "6e6f74736563726574",
+		payloads: []string{
+			"746573745f6d73675f30",
+			"746573745f6d73675f31",
+			"746573745f6d73675f32",
+			"79656c6c6f777375626d6172696e65",
+			"7375626d6172696e6579656c6c6f77",
+		},
+		ciphertexts: []string{
+			"358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254746573745f6d73675f30",
+			"64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d484665393019dbd6f438795da206db0886610b26108e424142c2e9b5fd1f7ea70cde847f6866f15c3cd3f864f7ed682f1711a4917917195c8cf360e080035dfa88af5c6e9b820278e6016f7d7",
+			"e610eadc4b00c17708bf223f29a66f02342fbedf6c0044736544b9271821ae403bbe475185a4a265a50e1d43bdaeee7fe070c07602c6b84d25a3b4064af5be30115a052069038f5002a3",
+			"9ea1da1ec3bfecfffab213e537ed1791bfa887dd9c631351b3f63d6315ab9a",
+			"217c5111fad7afde33bd28abaff3def88a57ab50515115d23a10f28621f842",
+		},
+	},
+	{
+		pattern:       noise.PatternIK,
+		initStatic:    "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		respStatic:    "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		initEphemeral: "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+		respEphemeral: "4142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60",
+		prologue:      "6e6f74736563726574",
+		payloads: []string{
+			"746573745f6d73675f30",
+			"746573745f6d73675f31",
+			"79656c6c6f777375626d6172696e65",
+			"7375626d6172696e6579656c6c6f77",
+		},
+		ciphertexts: []string{
+			"358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd16625419d6fab175300a577115c701c41ed681373f0432f81d3bf8676bd05216cd1919e61b75ccef0c0cf0b216fcdf371d0859e6d8177aa9777fe9b8435bb6f8202c3acd9051a9aee0a63e76f6",
+			"64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d4846658a7bb8caac5097833909e90778571d34ce0e5b6ea4c3a76f102",
+			"80a75e75c8e8d2e9c2a6c7bc6e550c4997d6d2b45429a530821c4aa5d36f27",
+			"b8475410da62a98493d33a1e669f8f56dd8f61d449b53bd375299c3435424a",
+		},
+	},
+}
+
+// checkNoiseVector refaz o vetor v dos dois lados e confere cada mensagem.
+func checkNoiseVector(v noiseVector) error {
+	key := func(h string) *ecdh.PrivateKey {
+		k, err := ecdh.X25519().NewPrivateKey(mustHex(h))
+		if err != nil {
+			panic(err)
+		}
+		return k
+	}
+	iStatic, rStatic := key(v.initStatic), key(v.respStatic)
+	cfg := noise.Config{
+		Pattern:    v.pattern,
+		Prologue:   mustHex(v.prologue),
+		CipherFunc: aesGCM{},
+		HashFunc:   sha256Func{},
+	}
+	ic, rc := cfg, cfg
+	ic.Initiator, ic.StaticKey, ic.Ephemeral = true, iStatic, key(v.initEphemeral)
+	rc.StaticKey, rc.Ephemeral = rStatic, key(v.respEphemeral)
+	if v.pattern.PreResponderS {
+		ic.PeerStatic = rStatic.PublicKey()
+	}
+	ini, err := noise.NewHandshakeState(ic)
+	if err != nil {
+		return err
+	}
+	resp, err := noise.NewHandshakeState(rc)
+	if err != nil {
+		return err
+	}
+
+	parties := [2]*noise.HandshakeState{ini, resp}
+	var send, recv [2]*noise.CipherState
+	hs := 0 // mensagens do handshake
+	for m := range v.payloads {
+		if !ini.Finished() {
+			hs = m + 1
+		}
+		w, r := m%2, (m+1)%2
+		if m >= hs {
+			w, r = (m-hs)%2, (m-hs+1)%2
+		}
+		payload, want := mustHex(v.payloads[m]), mustHex(v.ciphertexts[m])
+		var ct, pt []byte
+		if !ini.Finished() {
+			var s1, r1, s2, r2 *noise.CipherState
+			if ct, s1, r1, err = parties[w].WriteMessage(nil, payload); err != nil {
+				return fmt.Errorf("mensagem %d: %v", m, err)
+			}
+			if pt, s2, r2, err = parties[r].ReadMessage(nil, want); err != nil {
+				return fmt.Errorf("mensagem %d: %v", m, err)
+			}
+			send[w], recv[w], send[r], recv[r] = s1, r1, s2, r2
+		} else {
+			if ct, err = send[w].EncryptWithAd(nil, nil, payload); err != nil {
+				return fmt.Errorf("mensagem %d: %v", m, err)
+			}
+			if pt, err = recv[r].DecryptWithAd(nil, nil, want); err != nil {
+				return fmt.Errorf("mensagem %d: %v", m, err)
+			}
+		}
+		if !bytes.Equal(ct, want) || !bytes.Equal(pt, payload) {
+			return fmt.Errorf("mensagem %d não confere:\n%x\n%x", m, ct, want)
+		}
+	}
+	return nil
+}
+
+// noiseRun troca as mensagens do padrão entre dois HandshakeStates, com um
+// payload em cada uma, e devolve os CipherStates de cada lado. tamper, se
+// não for nil, é chamada com cada mensagem antes da leitura.
+func noiseRun(ini, resp *noise.HandshakeState, msgs int, tamper func([]byte)) (iSend, iRecv, rSend, rRecv *noise.CipherState, err error) {
+	parties := [2]*noise.HandshakeState{ini, resp}
+	var send, recv [2]*noise.CipherState
+	for m := 0; m < msgs; m++ {
+		w, r := parties[m%2], parties[(m+1)%2]
+		payload := []byte(fmt.Sprintf("payload %d", m))
+		msg, s1, r1, err := w.WriteMessage(nil, payload)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if tamper != nil {
+			tamper(msg)
+		}
+		got, s2, r2, err := r.ReadMessage(nil, msg)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if !bytes.Equal(got, payload) {
+			return nil, nil, nil, nil, fmt.Errorf("payload da mensagem %d não confere", m)
+		}
+		send[m%2], recv[m%2] = s1, r1
+		send[(m+1)%2], recv[(m+1)%2] = s2, r2
+	}
+	return send[0], recv[0], send[1], recv[1], nil
+}
+
+// checkTransport envia mensagens nos dois sentidos, troca a chave no meio e
+// confere que um texto cifrado adulterado é rejeitado.
+func checkTransport(iSend, iRecv, rSend, rRecv *noise.CipherState) error {
+	for i := 0; i < 6; i++ {
+		if i == 3 {
+			iSend.Rekey()
+			rRecv.Rekey()
+		}
+		for _, dir := range [][2]*noise.CipherState{{iSend, rRecv}, {rSend, iRecv}} {
+			msg := randomBytes(10 * i)
+			ct, err := dir[0].EncryptWithAd(nil, nil, msg)
+			if err != nil {
+				return err
+			}
+			pt, err := dir[1].DecryptWithAd(nil, nil, ct)
+			if err != nil || !bytes.Equal(pt, msg) {
+				return fmt.Errorf("mensagem de transporte %d não confere (%v)", i, err)
+			}
+		}
+	}
+	ct, _ := iSend.EncryptWithAd(nil, nil, []byte("x"))
+	ct[0] ^= 1
+	n := rRecv.Nonce()
+	if _, err := rRecv.DecryptWithAd(nil, nil, ct); err == nil || rRecv.Nonce() != n {
+		return fmt.Errorf("mensagem adulterada aceita ou contador avançou")
+	}
+	return nil
+}
+
+func testNoise() {
+	fmt.Println("\n🎼 Noise: Noise_XX/IK_25519_GingaOCB_GingaHash")
+	failures := 0
+	fail := func(format string, args ...any) {
+		fmt.Printf("❌ "+format+"\n", args...)
+		failures++
+	}
+
+	if noise.HashGinga.HashLen() != 32 || noise.HashGinga.BlockLen() < noise.HashGinga.HashLen() {
+		fail("GingaHash não atende HASHLEN = 32 e BLOCKLEN ≥ HASHLEN")
+	}
+
+	for _, v := range noiseVectors {
+		if err := checkNoiseVector(v); err != nil {
+			fail("vetor Noise_%s_25519_AESGCM_SHA256: %v", v.pattern.Name, err)
+		}
+	}
+
+	iKey, rKey, otherKey := newStaticKey(), newStaticKey(), newStaticKey()
+	prologue := []byte("ginga noise")
+	newPair := func(p noise.Pattern, peer *ecdh.PublicKey) (*noise.HandshakeState, *noise.HandshakeState) {
+		ini, err := noise.NewHandshakeState(noise.Config{Pattern: p, Initiator: true, Prologue: prologue, StaticKey: iKey, PeerStatic: peer})
+		if err != nil {
+			panic(err)
+		}
+		resp, err := noise.NewHandshakeState(noise.Config{Pattern: p, Prologue: prologue, StaticKey: rKey})
+		if err != nil {
+			panic(err)
+		}
+		return ini, resp
+	}
+
+	for _, c := range []struct {
+		p    noise.Pattern
+		msgs int
+	}{{noise.PatternXX, 3}, {noise.PatternIK, 2}} {
+		ini, resp := newPair(c.p, rKey.PublicKey())
+		iSend, iRecv, rSend, rRecv, err := noiseRun(ini, resp, c.msgs, nil)
+		switch {
+		case err != nil:
+			fail("%s: %v", c.p.Name, err)
+		case iSend == nil || rRecv == nil:
+			fail("%s: Split não aconteceu na última mensagem", c.p.Name)
+		case !bytes.Equal(ini.HandshakeHash(), resp.HandshakeHash()):
+			fail("%s: hashes de handshake diferentes", c.p.Name)
+		case !ini.PeerStatic().Equal(rKey.PublicKey()) || !resp.PeerStatic().Equal(iKey.PublicKey()):
+			fail("%s: chaves estáticas do par erradas", c.p.Name)
+		default:
+			if err := checkTransport(iSend, iRecv, rSend, rRecv); err != nil {
+				fail("%s: %v", c.p.Name, err)
+			}
+		}
+		if _, _, _, err := ini.WriteMessage(nil, nil); err == nil {
+			fail("%s: mensagem aceita depois do fim do handshake", c.p.Name)
+		}
+
+		// Um bit trocado em qualquer mensagem derruba o handshake.
+		for m := 0; m < c.msgs; m++ {
+			ini, resp := newPair(c.p, rKey.PublicKey())
+			at := 0
+			_, _, _, _, err := noiseRun(ini, resp, c.msgs, func(msg []byte) {
+				if at == m {
+					msg[len(msg)-1-m] ^= 1
+				}
+				at++
+			})
+			if err == nil {
+				fail("%s: mensagem %d adulterada aceita", c.p.Name, m+1)
+			}
+		}
+	}
+
+	// A mensagem errada é rejeitada sem estragar o estado: a original ainda
+	// é aceita depois.
+	ini, resp := newPair(noise.PatternXX, nil)
+	msg, _, _, _ := ini.WriteMessage(nil, nil)
+	if _, _, _, err := resp.WriteMessage(nil, nil); err == nil {
+		fail("XX: respondedor escreveu fora da vez")
+	}
+	if _, _, _, err := resp.ReadMessage(nil, msg); err != nil {
+		fail("XX: primeira mensagem recusada: %v", err)
+	}
+	msg, _, _, _ = resp.WriteMessage(nil, []byte("oi"))
+	for _, at := range []int{0, 40, len(msg) - 1} {
+		bad := bytes.Clone(msg)
+		bad[at] ^= 1
+		if _, _, _, err := ini.ReadMessage(nil, bad); err == nil {
+			fail("XX: segunda mensagem adulterada no byte %d aceita", at)
+		}
+	}
+	if _, _, _, err := ini.ReadMessage(nil, msg[:20]); err == nil {
+		fail("XX: mensagem truncada aceita")
+	}
+	if p, _, _, err := ini.ReadMessage(nil, msg); err != nil || string(p) != "oi" {
+		fail("XX: estado alterado por mensagem recusada (%v)", err)
+	}
+
+	// Mensagens acima de 65535 bytes são recusadas nos dois sentidos, sem
+	// alterar o estado. A primeira de XX é e || payload, em claro.
+	ini, resp = newPair(noise.PatternXX, nil)
+	if _, _, _, err := ini.WriteMessage(nil, make([]byte, noise.MaxMessageLen-31)); err == nil {
+		fail("XX: mensagem de %d bytes escrita", noise.MaxMessageLen+1)
+	}
+	if _, _, _, err := resp.ReadMessage(nil, make([]byte, noise.MaxMessageLen+1)); err == nil {
+		fail("XX: mensagem de %d bytes lida", noise.MaxMessageLen+1)
+	}
+	msg, _, _, err := ini.WriteMessage(nil, make([]byte, noise.MaxMessageLen-32))
+	if err != nil || len(msg) != noise.MaxMessageLen {
+		fail("XX: mensagem de %d bytes recusada (%v)", noise.MaxMessageLen, err)
+	} else if _, _, _, err := resp.ReadMessage(nil, msg); err != nil {
+		fail("XX: mensagem de %d bytes não lida (%v)", noise.MaxMessageLen, err)
+	}
+	if _, _, _, err := resp.WriteMessage(nil, make([]byte, noise.MaxMessageLen)); err == nil {
+		fail("XX: segunda mensagem acima do limite escrita")
+	}
+	if msg, _, _, err = resp.WriteMessage(nil, nil); err != nil {
+		fail("XX: estado alterado por mensagem grande demais (%v)", err)
+	} else if _, _, _, err := ini.ReadMessage(nil, msg); err != nil {
+		fail("XX: segunda mensagem depois da recusada não lida (%v)", err)
+	}
+
+	// O contador 2^64-1 é reservado.
+	cs := noise.NewCipherState(noise.CipherGinga)
+	cs.InitializeKey([32]byte{1})
+	cs.SetNonce(math.MaxUint64)
+	if _, err := cs.EncryptWithAd(nil, nil, nil); err != noise.ErrMaxNonce {
+		fail("contador esgotado: %v; esperado ErrMaxNonce", err)
+	}
+
+	// IK com a chave estática errada do respondedor.
+	ini, resp = newPair(noise.PatternIK, otherKey.PublicKey())
+	if _, _, _, _, err := noiseRun(ini, resp, 2, nil); err == nil {
+		fail("IK: respondedor aceitou iniciador que conhecia outra chave")
+	}
+
+	if failures == 0 {
+		fmt.Println("✅ Vetores AESGCM_SHA256 conferem; XX e IK completam, dividem as chaves, respeitam 65535 bytes e rejeitam adulterações; transporte com Rekey confere")
+	} else {
+		fmt.Printf("❌ %d verificações de Noise falharam\n", failures)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ CRIPTOANÁLISE ROTACIONAL E ROTACIONAL-XOR ============
+//
+// Um par rotacional é (x, x ⋘ γ), aplicado palavra a palavra. Rotações, XOR
+// entre variáveis e mixState32 preservam pares rotacionais com probabilidade
+// 1; a adição modular os preserva com probabilidade
+//
+//	P(γ) = (1 + 2^(γ-32) + 2^-γ + 2^-32) / 4    (Daum)
+//
+// e as constantes (0xA5A5A5A5, 0x3C3C3C3C e i*73 + r*91 da subchave) os
+// transformam em pares RX, (x, (x ⋘ γ) ⊕ δ). Aqui a diferença RX de um par
+// (y, y') é (y ⋘ γ) ⊕ y'; o par é rotacional quando ela é zero.
+
+func rotlState(s [4]uint32, gamma int) [4]uint32 {
+	for i := range s {
+		s[i] = gRotl(s[i], gamma)
+	}
+	return s
+}
+
+func rotlKey(k [8]uint32, gamma int) [8]uint32 {
+	for i := range k {
+		k[i] = gRotl(k[i], gamma)
+	}
+	return k
+}
+
+// rotationalAddProb é a probabilidade teórica de x + y preservar o par
+// rotacional de amplitude γ.
+func rotationalAddProb(gamma int) float64 {
+	g := float64(gamma)
+	return (1 + math.Pow(2, g-32) + math.Pow(2, -g) + math.Pow(2, -32)) / 4
+}
+
+// RotationalComponents mede, para uma palavra e amplitude γ, a probabilidade
+// de um par rotacional atravessar a adição com subchave, confuse32 e a
+// rodada r inteira (com subchave rotacionada), e a maior probabilidade de
+// uma diferença RX na saída da rodada.
+type RotationalComponents struct {
+	Add, Confuse, Round, RoundRX float64
+}
+
+func rotationalComponents(gamma, r, samples int) RotationalComponents {
+	var add, conf, round int
+	rx := map[uint32]int{}
+	for s := 0; s < samples; s++ {
+		x, k := randomWord(), randomWord()
+		xr, kr := gRotl(x, gamma), gRotl(k, gamma)
+		if xr+kr == gRotl(x+k, gamma) {
+			add++
+		}
+		if gConfuse(xr) == gRotl(gConfuse(x), gamma) {
+			conf++
+		}
+		d := gRotl(gRound(x, k, r), gamma) ^ gRound(xr, kr, r)
+		if d == 0 {
+			round++
+		}
+		rx[d]++
+	}
+	peak := 0
+	for _, c := range rx {
+		peak = max(peak, c)
+	}
+	n := float64(samples)
+	return RotationalComponents{
+		Add:     float64(add) / n,
+		Confuse: float64(conf) / n,
+		Round:   float64(round) / n,
+		RoundRX: float64(peak) / n,
+	}
+}
+
+// subKeyRX devolve, para chaves (k, k ⋘ γ), quantas das 4·Rounds subchaves
+// formam par rotacional e o peso de Hamming médio das diferenças RX. A
+// diferença é rotl(c ⊕ (c ⋘ γ), (r+i)&31) com c = i*73 + r*91, logo
+// independe da chave.
+func subKeyRX(gamma int) (rotational int, meanHW float64) {
+	k := loadKey(randomBytes(32))
+	kr := rotlKey(k, gamma)
+	total := 0
+	for r := 0; r < ginga.Rounds; r++ {
+		for i := 0; i < 4; i++ {
+			d := gRotl(gSubKey(&k, r, i), gamma) ^ gSubKey(&kr, r, i)
+			if d == 0 {
+				rotational++
+			}
+			total += bits.OnesCount32(d)
+		}
+	}
+	return rotational, float64(total) / float64(4*ginga.Rounds)
+}
+
+// mixRotational verifica que mixState32 preserva pares rotacionais.
+func mixRotational(gamma, samples int) bool {
+	for s := 0; s < samples; s++ {
+		x := loadState(randomBytes(16))
+		y := x
+		gMix(&y)
+		xr := rotlState(x, gamma)
+		gMix(&xr)
+		if xr != rotlState(y, gamma) {
+			return false
+		}
+	}
+	return true
+}
+
+// RotationalRounds cifra pares (x, x ⋘ γ) com chaves (k, k ⋘ γ) e devolve,
+// para cada número de rodadas 1..rounds, quantos pares saíram rotacionais e
+// a contagem da diferença RX de saída mais frequente. Se schedule é falso,
+// o segundo elemento usa as subchaves do primeiro rotacionadas, isolando a
+// parte de dados (round32 e mixState32) do escalonamento de chave.
+func RotationalRounds(gamma, rounds, samples int, schedule bool) (rotational, peakRX []int) {
+	rotational = make([]int, rounds+1)
+	peakRX = make([]int, rounds+1)
+	counts := make([]map[[4]uint32]int, rounds+1)
+	for r := range counts {
+		counts[r] = map[[4]uint32]int{}
+	}
+
+	for s := 0; s < samples; s++ {
+		k := loadKey(randomBytes(32))
+		kr := rotlKey(k, gamma)
+		x := loadState(randomBytes(16))
+		xr := rotlState(x, gamma)
+		for r := 0; r < rounds; r++ {
+			for i := 0; i < 4; i++ {
+				sk, skr := gSubKey(&k, r, i), gSubKey(&kr, r, i)
+				if !schedule {
+					skr = gRotl(sk, gamma)
+				}
+				x[i] = gRound(x[i], sk, r)
+				xr[i] = gRound(xr[i], skr, r)
+			}
+			gMix(&x)
+			gMix(&xr)
+			d := rotlState(x, gamma)
+			for i := range d {
+				d[i] ^= xr[i]
+			}
+			if d == [4]uint32{} {
+				rotational[r+1]++
+			}
+			counts[r+1][d]++
+		}
+	}
+	for r := 1; r <= rounds; r++ {
+		for _, c := range counts[r] {
+			peakRX[r] = max(peakRX[r], c)
+		}
+	}
+	return rotational, peakRX
+}
+
+func testRotational(wordSamples, pairSamples int) {
+	fmt.Printf("\n🔄 Componentes (par rotacional por palavra, %d amostras por γ):\n", wordSamples)
+	fmt.Println(" γ | x+k emp. | x+k teor. | confuse32 | round32 | RX round32 | subchaves rot. | HW RX subchave | mix")
+	for gamma := 1; gamma < 32; gamma++ {
+		c := rotationalComponents(gamma, 0, wordSamples)
+		rot, hw := subKeyRX(gamma)
+		mix := "✅"
+		if !mixRotational(gamma, 64) {
+			mix = "❌"
+		}
+		fmt.Printf("%2d | %8.5f | %9.5f | %9.5f | %7.5f | %10.5f | %8d/%-5d | %14.2f | %s\n",
+			gamma, c.Add, rotationalAddProb(gamma), c.Confuse, c.Round, c.RoundRX, rot, 4*ginga.Rounds, hw, mix)
+	}
+
+	rotationalRounds("subchaves rotacionadas", false, pairSamples)
+	rotationalRounds("chaves (k, k ⋘ γ)", true, pairSamples)
+}
+
+func rotationalRounds(label string, schedule bool, pairSamples int) {
+	fmt.Printf("\n🔁 Pares rotacionais e RX por rodada (Ginga, %s, %d pares por γ):\n", label, pairSamples)
+	rounds := ginga.Rounds
+	bestRot := make([]int, rounds+1)
+	bestRX := make([]int, rounds+1)
+	bestGamma := make([]int, rounds+1)
+	for gamma := 1; gamma < 32; gamma++ {
+		rot, rx := RotationalRounds(gamma, rounds, pairSamples, schedule)
+		for r := 1; r <= rounds; r++ {
+			bestRot[r] = max(bestRot[r], rot[r])
+			if rx[r] > bestRX[r] {
+				bestRX[r], bestGamma[r] = rx[r], gamma
+			}
+		}
+	}
+
+	for r := 1; r <= rounds; r++ {
+		// Numa permutação aleatória cada diferença RX tem probabilidade 2^-128;
+		// Bonferroni sobre as 31 amplitudes.
+		p := math.Min(1, 31*differentialPValue(bestRX[r], pairSamples, 16))
+		fmt.Printf("Rodadas %2d: pares rotacionais %d/%d, melhor RX (γ = %2d) %d/%d (p = %.3g)\n",
+			r, bestRot[r], pairSamples, bestGamma[r], bestRX[r], pairSamples, p)
+		if r == rounds && schedule {
+			record("Rotacional/Ginga", math.Min(1, 31*binomialSF(bestRot[r], pairSamples, math.Pow(2, -128))))
+			record("RX/Ginga", p)
+		}
+	}
+}
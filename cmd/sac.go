@@ -0,0 +1,294 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ============ SAC / BIC ============
+
+var (
+	csvDir = flag.String("csv", "", "diretório para exportar as matrizes SAC/BIC em CSV")
+	pngDir = flag.String("png", "", "diretório para exportar as matrizes SAC/BIC como mapa de calor PNG")
+)
+
+// Matrix é uma matriz densa de float64 (linhas × colunas).
+type Matrix struct {
+	Rows, Cols int
+	Data       []float64
+}
+
+func NewMatrix(rows, cols int) *Matrix {
+	return &Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+func (m *Matrix) At(i, j int) float64     { return m.Data[i*m.Cols+j] }
+func (m *Matrix) Set(i, j int, v float64) { m.Data[i*m.Cols+j] = v }
+
+// flipInput devolve cópias de (pt, key) com o bit i da entrada escolhida invertido.
+func flipInput(pt, key []byte, input string, i int) ([]byte, []byte) {
+	p := append([]byte(nil), pt...)
+	k := append([]byte(nil), key...)
+	if input == "key" {
+		k[i/8] ^= 1 << (i % 8)
+	} else {
+		p[i/8] ^= 1 << (i % 8)
+	}
+	return p, k
+}
+
+// SACMatrix estima P(bit j da saída inverte | bit i da entrada inverte) para
+// entradas aleatórias. input escolhe "plaintext" ou "key".
+func SACMatrix(encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize int, input string, samples int) *Matrix {
+	inBits := 8 * blockSize
+	if input == "key" {
+		inBits = 8 * keySize
+	}
+	outBits := 8 * blockSize
+	counts := make([]int, inBits*outBits)
+
+	pt := make([]byte, blockSize)
+	key := make([]byte, keySize)
+	for s := 0; s < samples; s++ {
+		rand.Read(pt)
+		rand.Read(key)
+		base, err := encryptFunc(pt, key)
+		if err != nil {
+			panic(err)
+		}
+		for i := 0; i < inBits; i++ {
+			p, k := flipInput(pt, key, input, i)
+			c, err := encryptFunc(p, k)
+			if err != nil {
+				panic(err)
+			}
+			row := counts[i*outBits:]
+			for j := 0; j < outBits; j++ {
+				row[j] += int(((c[j/8] ^ base[j/8]) >> (j % 8)) & 1)
+			}
+		}
+	}
+
+	m := NewMatrix(inBits, outBits)
+	for i, c := range counts {
+		m.Data[i] = float64(c) / float64(samples)
+	}
+	return m
+}
+
+// BICMatrix estima, para cada par de bits de saída (j, k), a maior correlação
+// absoluta entre suas inversões sobre todos os bits de entrada i.
+func BICMatrix(encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize int, input string, samples int) *Matrix {
+	inBits := 8 * blockSize
+	if input == "key" {
+		inBits = 8 * keySize
+	}
+	outBits := 8 * blockSize
+	bic := NewMatrix(outBits, outBits)
+
+	single := make([]int, outBits)
+	pair := make([]int, outBits*outBits)
+	flips := make([]int, 0, outBits)
+	pt := make([]byte, blockSize)
+	key := make([]byte, keySize)
+
+	for i := 0; i < inBits; i++ {
+		for j := range single {
+			single[j] = 0
+		}
+		for j := range pair {
+			pair[j] = 0
+		}
+
+		for s := 0; s < samples; s++ {
+			rand.Read(pt)
+			rand.Read(key)
+			base, err := encryptFunc(pt, key)
+			if err != nil {
+				panic(err)
+			}
+			p, k := flipInput(pt, key, input, i)
+			c, err := encryptFunc(p, k)
+			if err != nil {
+				panic(err)
+			}
+
+			flips = flips[:0]
+			for j := 0; j < outBits; j++ {
+				if ((c[j/8]^base[j/8])>>(j%8))&1 == 1 {
+					flips = append(flips, j)
+				}
+			}
+			for a, j := range flips {
+				single[j]++
+				for _, k := range flips[a+1:] {
+					pair[j*outBits+k]++
+				}
+			}
+		}
+
+		n := float64(samples)
+		for j := 0; j < outBits; j++ {
+			pj := float64(single[j]) / n
+			for k := j + 1; k < outBits; k++ {
+				pk := float64(single[k]) / n
+				den := math.Sqrt(pj * (1 - pj) * pk * (1 - pk))
+				if den == 0 {
+					continue
+				}
+				r := math.Abs((float64(pair[j*outBits+k])/n - pj*pk) / den)
+				if r > bic.At(j, k) {
+					bic.Set(j, k, r)
+					bic.Set(k, j, r)
+				}
+			}
+		}
+	}
+	return bic
+}
+
+func testSAC(name string, encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize int, input string, samples int) {
+	fmt.Printf("\n🧮 Matriz SAC (%s, entrada: %s, %d amostras):\n", name, input, samples)
+
+	m := SACMatrix(encryptFunc, blockSize, keySize, input, samples)
+	worst, wi, wj := 0.0, 0, 0
+	sum := 0.0
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			v := m.At(i, j)
+			sum += v
+			if d := math.Abs(v - 0.5); d > worst {
+				worst, wi, wj = d, i, j
+			}
+		}
+	}
+	fmt.Printf("Matriz %dx%d, média: %.5f\n", m.Rows, m.Cols, sum/float64(len(m.Data)))
+	fmt.Printf("Pior célula: entrada %d → saída %d: %.5f (desvio %.5f)\n", wi, wj, m.At(wi, wj), worst)
+
+	// H0: cada célula ~ Binomial(samples, 1/2)/samples; Šidák sobre todas as células.
+	z := worst / math.Sqrt(0.25/float64(samples))
+	cells := float64(len(m.Data))
+	record(fmt.Sprintf("SAC/%s/%s", name, input), -math.Expm1(cells*math.Log1p(-normalTwoSided(z))))
+
+	exportMatrix(fmt.Sprintf("sac_%s_%s", name, input), m, 0.5)
+}
+
+func testBIC(name string, encryptFunc func([]byte, []byte) ([]byte, error), blockSize, keySize int, input string, samples int) {
+	fmt.Printf("\n🔗 Matriz BIC (%s, entrada: %s, %d amostras por bit):\n", name, input, samples)
+
+	m := BICMatrix(encryptFunc, blockSize, keySize, input, samples)
+	worst, wj, wk := 0.0, 0, 0
+	for j := 0; j < m.Rows; j++ {
+		for k := j + 1; k < m.Cols; k++ {
+			if v := m.At(j, k); v > worst {
+				worst, wj, wk = v, j, k
+			}
+		}
+	}
+	fmt.Printf("Pior correlação |r|: saídas %d e %d: %.5f\n", wj, wk, worst)
+
+	// H0: r·√n ~ N(0,1); Šidák sobre entradas × pares de saída.
+	inBits := 8 * blockSize
+	if input == "key" {
+		inBits = 8 * keySize
+	}
+	cells := float64(inBits * m.Rows * (m.Rows - 1) / 2)
+	z := worst * math.Sqrt(float64(samples))
+	record(fmt.Sprintf("BIC/%s/%s", name, input), -math.Expm1(cells*math.Log1p(-normalTwoSided(z))))
+
+	exportMatrix(fmt.Sprintf("bic_%s_%s", name, input), m, 0)
+}
+
+// ============ EXPORTAÇÃO ============
+
+func exportMatrix(base string, m *Matrix, center float64) {
+	base = strings.ToLower(base)
+	if *csvDir != "" {
+		path := filepath.Join(*csvDir, base+".csv")
+		if err := writeMatrixCSV(path, m); err != nil {
+			fmt.Fprintln(os.Stderr, "erro ao exportar CSV:", err)
+		} else {
+			fmt.Println("CSV:", path)
+		}
+	}
+	if *pngDir != "" {
+		path := filepath.Join(*pngDir, base+".png")
+		if err := writeMatrixPNG(path, m, center); err != nil {
+			fmt.Fprintln(os.Stderr, "erro ao exportar PNG:", err)
+		} else {
+			fmt.Println("PNG:", path)
+		}
+	}
+}
+
+func writeMatrixCSV(path string, m *Matrix) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	row := make([]string, m.Cols)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			row[j] = strconv.FormatFloat(m.At(i, j), 'f', 6, 64)
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeMatrixPNG desenha a matriz como mapa de calor: branco em center,
+// vermelho acima e azul abaixo, saturando no maior desvio observado.
+func writeMatrixPNG(path string, m *Matrix, center float64) error {
+	const scale = 4
+
+	maxDev := 0.0
+	for _, v := range m.Data {
+		maxDev = math.Max(maxDev, math.Abs(v-center))
+	}
+	if maxDev == 0 {
+		maxDev = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, m.Cols*scale, m.Rows*scale))
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			t := (m.At(i, j) - center) / maxDev
+			fade := uint8(255 - 255*math.Abs(t))
+			c := color.RGBA{R: 255, G: fade, B: fade, A: 255}
+			if t < 0 {
+				c = color.RGBA{R: fade, G: fade, B: 255, A: 255}
+			}
+			for y := 0; y < scale; y++ {
+				for x := 0; x < scale; x++ {
+					img.Set(j*scale+x, i*scale+y, c)
+				}
+			}
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ ATAQUE POR DESLIZAMENTO / AUTO-SIMILARIDADE ============
+//
+// subKey32 escolhe k[(i+r)&7], então as subchaves repetem a estrutura com
+// período 8 a menos das constantes 73i + 91r e da rotação (r+i)&31. Um
+// deslizamento por s rodadas exige chaves K, K' com SK_K(r+s, i) = SK_K'(r, i)
+// e rodadas F_{r+s} = F_r; aqui medimos as duas condições separadamente.
+
+// subKeys devolve as 4·Rounds subchaves; sem constants, o termo 73i + 91r é
+// omitido (escalonamento hipotético, para medir o efeito das constantes).
+func subKeys(k *[8]uint32, constants bool) []uint32 {
+	sk := make([]uint32, 0, 4*ginga.Rounds)
+	for r := 0; r < ginga.Rounds; r++ {
+		for i := 0; i < 4; i++ {
+			if constants {
+				sk = append(sk, gSubKey(k, r, i))
+			} else {
+				sk = append(sk, gRotl(k[(i+r)&7], (r+i)&31))
+			}
+		}
+	}
+	return sk
+}
+
+// SelfSimilarity resume as repetições numa sequência de subchaves.
+type SelfSimilarity struct {
+	Distinct   int // subchaves distintas
+	Equal      int // pares (a, b) com SK_a = SK_b
+	Rotational int // pares com SK_a = SK_b ⋘ γ, 0 < γ < 32
+	Periods    []int
+}
+
+func selfSimilarity(sk []uint32) SelfSimilarity {
+	var s SelfSimilarity
+	seen := map[uint32]bool{}
+	for a, x := range sk {
+		seen[x] = true
+		for _, y := range sk[a+1:] {
+			if x == y {
+				s.Equal++
+				continue
+			}
+			for g := 1; g < 32; g++ {
+				if x == gRotl(y, g) {
+					s.Rotational++
+					break
+				}
+			}
+		}
+	}
+	s.Distinct = len(seen)
+
+	// Período em rodadas: SK(r+p, ·) = SK(r, ·) para todo r.
+	for p := 1; p < ginga.Rounds; p++ {
+		periodic := true
+		for j := 4 * p; j < len(sk) && periodic; j++ {
+			periodic = sk[j] == sk[j-4*p]
+		}
+		if periodic {
+			s.Periods = append(s.Periods, p)
+		}
+	}
+	return s
+}
+
+// slideKey resolve SK_K(r+s, i) = SK_K'(r, i) para K', palavra a palavra.
+// Cada palavra de K' recebe várias restrições: a primeira fixa o valor e as
+// demais (redundant) concordam ou entram em conflict.
+func slideKey(k *[8]uint32, s int, constants bool) (k2 [8]uint32, redundant, conflicts int) {
+	var set [8]bool
+	for r := 0; r+s < ginga.Rounds; r++ {
+		for i := 0; i < 4; i++ {
+			var v uint32
+			if constants {
+				v = gRotr(gSubKey(k, r+s, i), (r+i)&31) ^ uint32(i*73+r*91)
+			} else {
+				v = gRotr(gRotl(k[(i+r+s)&7], (r+s+i)&31), (r+i)&31)
+			}
+			j := (i + r) & 7
+			switch {
+			case !set[j]:
+				k2[j], set[j] = v, true
+			case k2[j] != v:
+				redundant++
+				conflicts++
+			default:
+				redundant++
+			}
+		}
+	}
+	return k2, redundant, conflicts
+}
+
+// slideShifts devolve os deslocamentos s para os quais existe K' que
+// satisfaz todas as restrições, desde que haja ao menos uma redundante (com
+// poucas rodadas sobrepostas, qualquer K admite K' trivialmente).
+func slideShifts(k *[8]uint32, constants bool) []int {
+	var shifts []int
+	for s := 1; s < ginga.Rounds; s++ {
+		if _, red, conf := slideKey(k, s, constants); red > 0 && conf == 0 {
+			shifts = append(shifts, s)
+		}
+	}
+	return shifts
+}
+
+// slidPairs conta, para x aleatório, quantas vezes as rodadas [s, Rounds) com
+// K coincidem com as rodadas [0, Rounds-s) com K' (pares deslizados).
+func slidPairs(k, k2 *[8]uint32, s, samples int) int {
+	hits := 0
+	for n := 0; n < samples; n++ {
+		x := loadState(randomBytes(16))
+		if encryptWords(x, k, s, ginga.Rounds) == encryptWords(x, k2, 0, ginga.Rounds-s) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// worst combina duas medidas ficando com o pior caso de cada campo; os
+// períodos são unidos.
+func (s SelfSimilarity) worst(o SelfSimilarity) SelfSimilarity {
+	return SelfSimilarity{
+		Distinct:   min(s.Distinct, o.Distinct),
+		Equal:      max(s.Equal, o.Equal),
+		Rotational: max(s.Rotational, o.Rotational),
+		Periods:    unionInts(s.Periods, o.Periods),
+	}
+}
+
+// unionInts une duas listas crescentes sem repetição.
+func unionInts(a, b []int) []int {
+	out := slices.Concat(a, b)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+var slideReasonOrder = []string{
+	"subchaves repetidas", "subchaves rotações umas das outras", "subchaves periódicas",
+	"escalonamento deslizável", "pares deslizados",
+}
+
+// slideReasons lista os problemas de uma chave, na ordem de slideReasonOrder.
+func slideReasons(sim SelfSimilarity, shifts []int, slid int) []string {
+	var reasons []string
+	if sim.Distinct < 4*ginga.Rounds || sim.Equal > 0 {
+		reasons = append(reasons, slideReasonOrder[0])
+	}
+	if sim.Rotational > 0 {
+		reasons = append(reasons, slideReasonOrder[1])
+	}
+	if len(sim.Periods) > 0 {
+		reasons = append(reasons, slideReasonOrder[2])
+	}
+	if len(shifts) > 0 {
+		reasons = append(reasons, slideReasonOrder[3])
+	}
+	if slid > 0 {
+		reasons = append(reasons, slideReasonOrder[4])
+	}
+	return reasons
+}
+
+func testSlide(keysPerClass, samples int) {
+	fmt.Println("\n🛝 Deslizamento e auto-similaridade do escalonamento (Ginga):")
+
+	// Parte de dados: round32 rotaciona por (r+3)&31 e (r+5)&31, então F_r e
+	// F_{r+s} só coincidem se s ≡ 0 (mod 32).
+	sameRound := 0
+	for s := 1; s < ginga.Rounds; s++ {
+		if (s+3)&31 == 3 && (s+5)&31 == 5 {
+			sameRound++
+		}
+	}
+	fmt.Printf("Deslocamentos s com F_{r+s} = F_r na parte de dados: %d / %d\n", sameRound, ginga.Rounds-1)
+
+	classes := append([]weakKeyClass{{"aleatória", func() []byte { return randomBytes(32) }}}, weakKeyClasses...)
+	fmt.Printf("Pior caso entre %d chaves por classe:\n", keysPerClass)
+	fmt.Println("classe                 | distintas (s/ const) | iguais | rotacionais | períodos | s deslizáveis (s/ const) | pares deslizados")
+
+	hits, probes := 0, 0
+	for _, class := range classes {
+		// O pior caso entre as chaves da classe vai para a tabela; reasons
+		// conta em quantas chaves cada problema aparece.
+		sim := SelfSimilarity{Distinct: 4 * ginga.Rounds}
+		simNC := sim
+		var shifts, shiftsNC []int
+		slid, affected := 0, 0
+		reasons := map[string]int{}
+		for n := 0; n < keysPerClass; n++ {
+			k := loadKey(class.gen())
+			ks := selfSimilarity(subKeys(&k, true))
+			ksh := slideShifts(&k, true)
+			sim = sim.worst(ks)
+			simNC = simNC.worst(selfSimilarity(subKeys(&k, false)))
+			shifts = unionInts(shifts, ksh)
+			shiftsNC = unionInts(shiftsNC, slideShifts(&k, false))
+
+			// Pares deslizados com o K' que satisfaz a maior parte das
+			// restrições, para todo s.
+			kslid := 0
+			for s := 1; s < ginga.Rounds; s++ {
+				k2, _, _ := slideKey(&k, s, true)
+				kslid += slidPairs(&k, &k2, s, samples)
+				probes += samples
+			}
+			slid += kslid
+			hits += kslid
+
+			kr := slideReasons(ks, ksh, kslid)
+			for _, r := range kr {
+				reasons[r]++
+			}
+			if len(kr) > 0 {
+				affected++
+			}
+		}
+
+		fmt.Printf("%-22s | %9d (%2d)       | %6d | %11d | %8d | %12d (%2d)      | %d\n",
+			class.name, sim.Distinct, simNC.Distinct, sim.Equal, sim.Rotational, len(sim.Periods),
+			len(shifts), len(shiftsNC), slid)
+		if len(shifts) > 0 {
+			fmt.Printf("  deslocamentos com K' consistente: %v\n", shifts)
+		}
+		if affected > 0 {
+			var parts []string
+			for _, r := range slideReasonOrder {
+				if c := reasons[r]; c > 0 {
+					parts = append(parts, fmt.Sprintf("%s (%d/%d chaves)", r, c, keysPerClass))
+				}
+			}
+			fmt.Printf("  ⚠️  %s: %d/%d chaves afetadas: %s\n", class.name, affected, keysPerClass, strings.Join(parts, ", "))
+		}
+	}
+	fmt.Printf("Pares deslizados encontrados: %d / %d\n", hits, probes)
+	record("Deslizamento/Ginga", binomialSF(hits, probes, math.Pow(2, -128)))
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ================ SIGNIFICÂNCIA ================
+
+var (
+	alpha      = flag.Float64("alpha", 0.01, "nível de significância global (família de testes)")
+	correction = flag.String("correction", "holm", "correção para comparações múltiplas: none, bonferroni, holm")
+)
+
+var corrections = []string{"none", "bonferroni", "holm"}
+
+// checkSignificanceFlags valida -alpha e -correction logo depois de
+// flag.Parse, antes de horas de testes.
+func checkSignificanceFlags() error {
+	if !(*alpha > 0 && *alpha < 1) {
+		return fmt.Errorf("-alpha deve estar entre 0 e 1, exclusive (recebido %g)", *alpha)
+	}
+	for _, c := range corrections {
+		if *correction == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("-correction desconhecida: %q (use none, bonferroni ou holm)", *correction)
+}
+
+// verdict guarda o p-valor de um teste sob a hipótese nula de que a saída
+// da cifra é indistinguível de uma permutação aleatória.
+type verdict struct {
+	name string
+	p    float64
+}
+
+var verdicts []verdict
+
+// record registra o p-valor de um teste para o relatório final.
+func record(name string, p float64) {
+	if math.IsNaN(p) || p > 1 {
+		p = 1
+	}
+	if p < 0 {
+		p = 0
+	}
+	verdicts = append(verdicts, verdict{name: name, p: p})
+	fmt.Printf("p-valor: %.6g\n", p)
+}
+
+// adjustPValues aplica a correção escolhida e devolve os p-valores ajustados
+// na mesma ordem de entrada.
+func adjustPValues(ps []float64, method string) ([]float64, error) {
+	m := len(ps)
+	adj := make([]float64, m)
+	switch method {
+	case "none":
+		copy(adj, ps)
+	case "bonferroni":
+		for i, p := range ps {
+			adj[i] = math.Min(1, p*float64(m))
+		}
+	case "holm":
+		idx := make([]int, m)
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.Slice(idx, func(a, b int) bool { return ps[idx[a]] < ps[idx[b]] })
+		running := 0.0
+		for rank, i := range idx {
+			v := math.Min(1, ps[i]*float64(m-rank))
+			if v > running {
+				running = v
+			}
+			adj[i] = running
+		}
+	default:
+		return nil, errors.New("correção desconhecida: " + method)
+	}
+	return adj, nil
+}
+
+func printVerdicts() {
+	if len(verdicts) == 0 {
+		return
+	}
+	fmt.Printf("\n== Veredito (α = %g, correção: %s, %d testes) ==\n", *alpha, *correction, len(verdicts))
+
+	ps := make([]float64, len(verdicts))
+	for i, v := range verdicts {
+		ps[i] = v.p
+	}
+	adj, err := adjustPValues(ps, *correction)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	failed := 0
+	for i, v := range verdicts {
+		status := "✅ PASSA"
+		if adj[i] < *alpha {
+			status = "❌ FALHA"
+			failed++
+		}
+		fmt.Printf("%-40s p = %-12.6g p-ajustado = %-12.6g %s\n", v.name, v.p, adj[i], status)
+	}
+	fmt.Printf("Rejeições da hipótese nula: %d / %d\n", failed, len(verdicts))
+}
+
+// ================ DISTRIBUIÇÕES ================
+
+// normalSF é a cauda superior da normal padrão, P(Z > z).
+func normalSF(z float64) float64 {
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+// normalTwoSided é o p-valor bilateral, P(|Z| > |z|).
+func normalTwoSided(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// chiSquaredSF é a cauda superior da qui-quadrado com dof graus de liberdade.
+func chiSquaredSF(x float64, dof int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return igamc(float64(dof)/2, x/2)
+}
+
+// igam é a função gama incompleta regularizada inferior P(a, x).
+func igam(a, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x > a+1 {
+		return 1 - igamc(a, x)
+	}
+	lg, _ := math.Lgamma(a)
+	sum, term := 1/a, 1/a
+	for n := 1; n < 10000; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*1e-15 {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lg)
+}
+
+// igamc é a função gama incompleta regularizada superior Q(a, x),
+// avaliada por fração contínua (Lentz) quando x > a+1.
+func igamc(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	if x <= a+1 {
+		return 1 - igam(a, x)
+	}
+	const tiny = 1e-300
+	lg, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 10000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+func binomialLogPMF(k, n int, p float64) float64 {
+	if p == 0 {
+		if k == 0 {
+			return 0
+		}
+		return math.Inf(-1)
+	}
+	if p == 1 {
+		if k == n {
+			return 0
+		}
+		return math.Inf(-1)
+	}
+	return logChoose(n, k) + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p)
+}
+
+// binomialSF devolve P(X >= k) para X ~ Binomial(n, p), somando a cauda exata.
+func binomialSF(k, n int, p float64) float64 {
+	if k <= 0 {
+		return 1
+	}
+	if k > n {
+		return 0
+	}
+	s := 0.0
+	for i := k; i <= n; i++ {
+		s += math.Exp(binomialLogPMF(i, n, p))
+	}
+	return math.Min(1, s)
+}
+
+// binomialCDF devolve P(X <= k) para X ~ Binomial(n, p).
+func binomialCDF(k, n int, p float64) float64 {
+	if k < 0 {
+		return 0
+	}
+	if k >= n {
+		return 1
+	}
+	s := 0.0
+	for i := 0; i <= k; i++ {
+		s += math.Exp(binomialLogPMF(i, n, p))
+	}
+	return math.Min(1, s)
+}
+
+// ================ P-VALORES DOS TESTES ================
+
+// linearBiasPValue: sob H0, cada viés |X/n - 1/2| segue uma meia-normal com
+// σ = 1/(2√n); a média de k iterações é aproximada por uma normal
+// (teste unilateral, viés alto demais).
+func linearBiasPValue(meanBias float64, trials, iterations int) float64 {
+	sigma := 1 / (2 * math.Sqrt(float64(trials)))
+	mu := sigma * math.Sqrt(2/math.Pi)
+	sd := sigma * math.Sqrt((1-2/math.Pi)/float64(iterations))
+	return normalSF((meanBias - mu) / sd)
+}
+
+// differentialPValue: sob H0 cada ΔC é uniforme em 2^(8·blockSize) valores;
+// limita P(algum ΔC aparecer >= c vezes em n amostras) por C(n,c)·2^(-bits·(c-1)).
+func differentialPValue(maxCount, samples, blockSize int) float64 {
+	if maxCount <= 1 {
+		return 1
+	}
+	logP := logChoose(samples, maxCount) - float64(8*blockSize*(maxCount-1))*math.Ln2
+	return math.Min(1, math.Exp(logP))
+}
+
+// ================ INTERVALOS DE CONFIANÇA ================
+
+// normalQuantile é a inversa da CDF normal padrão (algoritmo de Acklam,
+// erro relativo < 1.2e-9).
+func normalQuantile(p float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	a := [...]float64{-3.969683028665376e+01, 2.209460984245205e+02, -2.759285104469687e+02, 1.383577518672690e+02, -3.066479806614716e+01, 2.506628277459239e+00}
+	b := [...]float64{-5.447609879822406e+01, 1.615858368580409e+02, -1.556989798598866e+02, 6.680131188771972e+01, -1.328068155288572e+01}
+	c := [...]float64{-7.784894002430293e-03, -3.223964580411365e-01, -2.400758277161838e+00, -2.549732539343734e+00, 4.374664141464968e+00, 2.938163982698783e+00}
+	d := [...]float64{7.784695709041462e-03, 3.224671290700398e-01, 2.445134137142996e+00, 3.754408661907416e+00}
+
+	const low = 0.02425
+	switch {
+	case p < low:
+		q := math.Sqrt(-2 * math.Log(p))
+		return (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	case p > 1-low:
+		q := math.Sqrt(-2 * math.Log1p(-p))
+		return -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	default:
+		q := p - 0.5
+		r := q * q
+		return (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q / (((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1)
+	}
+}
+
+// wilsonInterval devolve o intervalo de Wilson com confiança 1-a para uma
+// proporção k/n.
+func wilsonInterval(k, n int, a float64) (lo, hi float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	z := normalQuantile(1 - a/2)
+	nf := float64(n)
+	p := float64(k) / nf
+	den := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / den
+	half := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / den
+	return math.Max(0, center-half), math.Min(1, center+half)
+}
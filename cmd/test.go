@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/rand"
-	"encoding/hex"
+	"flag"
 	"fmt"
 	"math"
 	"math/bits"
+	"os"
+	"sort"
 
 	"github.com/pedroalbanese/ginga"
 	"github.com/RyuaNerin/go-krypto/lea"
@@ -168,53 +171,54 @@ func testBoomerang(name string, encryptFunc func([]byte, []byte) ([]byte, error)
 	fmt.Printf("\n🪃 Teste Boomerang (%s):\n", name)
 	samples := 1000
 
-	deltas := []byte{
-		0x01, 0x02, 0x08, 0x10, 0x80, 0xC0, 0xF0, 0x3C, 0xA5, 0xFF,
+	// Diferenças de um bit espalhadas pelo bloco; o retorno é verificado nos
+	// 16 bytes.
+	var deltas [][]byte
+	for _, bit := range []int{0, 7, 8, 31, 32, 63, 64, 127} {
+		deltas = append(deltas, SingleBitDeltas(16)[bit])
 	}
 
 	bestCorr := 0.0
-	var bestDeltaP, bestDeltaC byte
+	bestMatches := 0
+	var bestDeltaP, bestDeltaC []byte
 
 	for _, deltaP := range deltas {
 		for _, deltaC := range deltas {
 			matches := 0
 
 			for i := 0; i < samples; i++ {
-				P1 := make([]byte, 16)
-				rand.Read(P1)
-
-				P2 := make([]byte, 16)
-				copy(P2, P1)
-				P2[0] ^= deltaP
+				P1 := randomBytes(16)
+				P2 := xorBytes(P1, deltaP)
 
 				C1, _ := encryptFunc(P1, key)
 				C2, _ := encryptFunc(P2, key)
 
-				C1p := make([]byte, 16)
-				C2p := make([]byte, 16)
-				copy(C1p, C1)
-				copy(C2p, C2)
-				C1p[0] ^= deltaC
-				C2p[0] ^= deltaC
-
-				D1, _ := decryptFunc(C1p, key)
-				D2, _ := decryptFunc(C2p, key)
+				D1, _ := decryptFunc(xorBytes(C1, deltaC), key)
+				D2, _ := decryptFunc(xorBytes(C2, deltaC), key)
 
-				if D1[0]^D2[0] == deltaP {
+				if bytes.Equal(xorBytes(D1, D2), deltaP) {
 					matches++
 				}
 			}
 
 			corr := float64(matches) / float64(samples)
-			if corr > bestCorr {
+			if corr > bestCorr || bestDeltaP == nil {
 				bestCorr = corr
+				bestMatches = matches
 				bestDeltaP = deltaP
 				bestDeltaC = deltaC
 			}
 		}
 	}
 
-	fmt.Printf("🌟 Melhor ΔP: 0x%02X | ΔC: 0x%02X → Correlação: %.5f\n", bestDeltaP, bestDeltaC, bestCorr)
+	lo, hi := wilsonInterval(bestMatches, samples, *alpha)
+	fmt.Printf("🌟 Melhor ΔP: %x | ΔC: %x → Retorno: %.5f [%.3g, %.3g]\n", bestDeltaP, bestDeltaC, bestCorr, lo, hi)
+
+	// H0: D1⊕D2 é uniforme em 128 bits, logo acerta ΔP com probabilidade
+	// 2^-128. O melhor de len(deltas)² pares é corrigido por Šidák.
+	cells := float64(len(deltas) * len(deltas))
+	pCell := binomialSF(bestMatches, samples, math.Pow(2, -128))
+	record("Boomerang/"+name, -math.Expm1(cells*math.Log1p(-pCell)))
 }
 
 // ================ Chi² ================
@@ -243,6 +247,7 @@ func testChiSquared(name string, encryptFunc func([]byte) []byte, blockSize int)
 	}
 
 	fmt.Printf("🔍 Valor Chi-Squared: %.2f\n", chiSquared)
+	record("Chi²/"+name, chiSquaredSF(chiSquared, 255))
 }
 
 // ================ DIFFUSION ================
@@ -253,6 +258,7 @@ func testDiffusion(name string, encryptFunc func([]byte) []byte, blockSize int)
 	base := make([]byte, blockSize)
 	rand.Read(base)
 	original := encryptFunc(base)
+	totalDiff := 0
 
 	for i := 0; i < blockSize; i++ {
 		mod := make([]byte, blockSize)
@@ -266,8 +272,12 @@ func testDiffusion(name string, encryptFunc func([]byte) []byte, blockSize int)
 				byteDiff++
 			}
 		}
+		totalDiff += byteDiff
 		fmt.Printf("Byte %2d modificado → %2d/%2d bytes diferentes\n", i, byteDiff, blockSize)
 	}
+
+	// H0: cada byte da saída difere com probabilidade 255/256 (cauda inferior).
+	record("Difusão/"+name, binomialCDF(totalDiff, blockSize*blockSize, 255.0/256))
 }
 
 // ================ ByteUniformity ================
@@ -297,6 +307,10 @@ func testByteUniformity(name string, encryptFunc func([]byte) []byte, blockSize
 		}
 	}
 	fmt.Printf("Entropia estimada: %.4f bits (máx. teórica: 8.0)\n", entropia)
+
+	// Teste G: G = 2·N·ln2·(8 - H) segue qui-quadrado com 255 g.l. sob H0.
+	g := 2 * total * math.Ln2 * (8 - entropia)
+	record("Entropia/"+name, chiSquaredSF(g, 255))
 }
 
 // ============ Bit Distribution ============
@@ -316,6 +330,10 @@ func testBitDistribution(name string, encryptFunc func([]byte) []byte, blockSize
 		}
 	}
 	fmt.Printf("Bits '1' na saída: %d / %d (%.2f%%)\n", ones, totalBits, 100*float64(ones)/float64(totalBits))
+
+	// H0: ones ~ Binomial(totalBits, 1/2), aproximação normal bilateral.
+	z := (float64(ones) - float64(totalBits)/2) / math.Sqrt(float64(totalBits)/4)
+	record("Bits/"+name, normalTwoSided(z))
 }
 
 // ============ GlobalAvalanchePlain ============
@@ -378,6 +396,12 @@ func testGlobalAvalanchePlain(name string, encryptFunc func([]byte, []byte) ([]b
 	fmt.Printf("Média de bits alterados: %.2f / %d (%.2f%%)\n", mean, totalBits, 100*mean/float64(blockSize*8))
 	fmt.Printf("Desvio padrão: %.2f bits\n", stddev)
 	fmt.Printf("Mínimo: %d bits, Máximo: %d bits\n", min, max)
+
+	// H0: cada bit da saída inverte com probabilidade 1/2, logo a soma dos
+	// flips segue Binomial(len(diffs)·8·blockSize, 1/2).
+	n := float64(len(diffs) * 8 * blockSize)
+	z := (sum - n/2) / math.Sqrt(n/4)
+	record("Avalanche/"+name, normalTwoSided(z))
 }
 
 // ============ Walsh-Hadamard Spectrum ============
@@ -439,6 +463,11 @@ func testWalshSpectrum(name string, encryptFunc func([]byte) []byte, blockSize i
 	}
 	nonlinearity := (numInputs - maxAbs) / 2
 	fmt.Printf("🧠 Não-linearidade estimada: %d (máx. possível: %d)\n", nonlinearity, numInputs/2)
+
+	// H0: para uma função aleatória cada coeficiente é ~N(0, numInputs);
+	// p-valor do máximo entre numInputs coeficientes.
+	pCoef := math.Erfc(float64(maxAbs) / math.Sqrt(2*float64(numInputs)))
+	record("Walsh/"+name, -math.Expm1(float64(numInputs)*math.Log1p(-pCoef)))
 }
 
 // ================ LINEAR ================
@@ -484,56 +513,125 @@ func LinearAnalysis(cipherFunc func([]byte) []byte, test LinearTest, iterations
 
 // ============ DIFFERENTIAL ==============
 
-func DifferentialAnalysis(cipherFunc func([]byte) []byte, deltaIn []byte, trials int) map[string]int {
-	stats := make(map[string]int)
+// DeltaCount associa uma diferença de saída ao número de ocorrências.
+type DeltaCount struct {
+	Delta [16]byte
+	Count int
+}
+
+func DifferentialAnalysis(cipherFunc func([]byte) []byte, deltaIn []byte, trials int) map[[16]byte]int {
+	stats := make(map[[16]byte]int)
+	pt2 := make([]byte, 16)
 	for i := 0; i < trials; i++ {
 		pt1 := randomBytes(16)
-		pt2 := xorBytes(pt1, deltaIn)
+		for j := range pt2 {
+			pt2[j] = pt1[j] ^ deltaIn[j]
+		}
 		ct1 := cipherFunc(pt1)
 		ct2 := cipherFunc(pt2)
-		deltaOut := xorBytes(ct1, ct2)
-		stats[hex.EncodeToString(deltaOut)]++
-	}
-	return stats
-}
 
-func printTopDeltas(stats map[string]float64) {
-	total := 0.0
-	for _, v := range stats {
-		total += v
-	}
-	fmt.Println("Top 5 deltas:")
-	count := 0
-	for k, v := range stats {
-		fmt.Printf("ΔC: %s → %.5f\n", k, v/total)
-		count++
-		if count >= 5 {
-			break
+		var deltaOut [16]byte
+		for j := range deltaOut {
+			deltaOut[j] = ct1[j] ^ ct2[j]
 		}
+		stats[deltaOut]++
 	}
+	return stats
 }
 
-func DifferentialAnalysisWithIterations(cipherFunc func([]byte) []byte, deltaIn []byte, trials int, iterations int) map[string]float64 {
-	aggregatedStats := make(map[string]int)
+func DifferentialAnalysisWithIterations(cipherFunc func([]byte) []byte, deltaIn []byte, trials int, iterations int) map[[16]byte]int {
+	aggregatedStats := make(map[[16]byte]int)
 	for i := 0; i < iterations; i++ {
 		stats := DifferentialAnalysis(cipherFunc, deltaIn, trials)
 		for k, v := range stats {
 			aggregatedStats[k] += v
 		}
 	}
+	return aggregatedStats
+}
+
+// TopDeltas ordena as diferenças de saída por contagem (desempate pelo valor)
+// e devolve as n mais frequentes.
+func TopDeltas(stats map[[16]byte]int, n int) []DeltaCount {
+	all := make([]DeltaCount, 0, len(stats))
+	for k, v := range stats {
+		all = append(all, DeltaCount{Delta: k, Count: v})
+	}
+	sort.Slice(all, func(a, b int) bool {
+		if all[a].Count != all[b].Count {
+			return all[a].Count > all[b].Count
+		}
+		return bytes.Compare(all[a].Delta[:], all[b].Delta[:]) < 0
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+func printTopDeltas(name string, stats map[[16]byte]int, n int) {
+	samples := 0
+	for _, v := range stats {
+		samples += v
+	}
+	top := TopDeltas(stats, n)
+
+	fmt.Printf("Top %d deltas (%d amostras, %d distintos, IC %.0f%%):\n", len(top), samples, len(stats), 100*(1-*alpha))
+	for _, d := range top {
+		lo, hi := wilsonInterval(d.Count, samples, *alpha)
+		fmt.Printf("ΔC: %x → %d (%.5f) [%.5f, %.5f]\n", d.Delta, d.Count, float64(d.Count)/float64(samples), lo, hi)
+	}
+
+	maxCount := 0
+	if len(top) > 0 {
+		maxCount = top[0].Count
+	}
+	record("Diferencial/"+name, differentialPValue(maxCount, samples, 16))
+}
+
+// SingleBitDeltas gera todas as diferenças de entrada com um único bit ativo.
+func SingleBitDeltas(blockSize int) [][]byte {
+	deltas := make([][]byte, 0, 8*blockSize)
+	for i := 0; i < 8*blockSize; i++ {
+		d := make([]byte, blockSize)
+		d[i/8] = 1 << (i % 8)
+		deltas = append(deltas, d)
+	}
+	return deltas
+}
+
+// DifferentialSweep percorre várias diferenças de entrada e reporta as n
+// melhores transições ΔP → ΔC encontradas. O p-valor do melhor ΔC é
+// corrigido por Bonferroni sobre o número de ΔP testados.
+func DifferentialSweep(name string, cipherFunc func([]byte) []byte, deltas [][]byte, trials int, n int) {
+	fmt.Printf("\n🔎 Varredura diferencial (%s): %d ΔP × %d amostras\n", name, len(deltas), trials)
 
-	// Normalize to get average occurrences
-	total := 0
-	for _, v := range aggregatedStats {
-		total += v
+	type transition struct {
+		deltaIn []byte
+		DeltaCount
+	}
+	var best []transition
+	for _, deltaIn := range deltas {
+		for _, d := range TopDeltas(DifferentialAnalysis(cipherFunc, deltaIn, trials), n) {
+			best = append(best, transition{deltaIn: deltaIn, DeltaCount: d})
+		}
+	}
+	sort.SliceStable(best, func(a, b int) bool { return best[a].Count > best[b].Count })
+	if len(best) > n {
+		best = best[:n]
 	}
 
-	averageStats := make(map[string]float64)
-	for k, v := range aggregatedStats {
-		averageStats[k] = float64(v) / float64(total)
+	for _, t := range best {
+		lo, hi := wilsonInterval(t.Count, trials, *alpha)
+		fmt.Printf("ΔP: %x → ΔC: %x → %d (%.5f) [%.5f, %.5f]\n", t.deltaIn, t.Delta, t.Count, float64(t.Count)/float64(trials), lo, hi)
 	}
 
-	return averageStats
+	maxCount := 0
+	if len(best) > 0 {
+		maxCount = best[0].Count
+	}
+	p := differentialPValue(maxCount, trials, 16) * float64(len(deltas))
+	record("Varredura diferencial/"+name, math.Min(1, p))
 }
 
 // ============ PARTIAL BLOCK TESTS ==============
@@ -552,6 +650,14 @@ func TestPartialBlocks(cipherFunc func([]byte) []byte) {
 // =============== MAIN ===============
 
 func main() {
+	flag.Parse()
+	if err := checkSignificanceFlags(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	checkReducedGinga()
+
 	// Linear
 	test := LinearTest{
 		PlainMask:  []int{0, 5, 9},
@@ -569,19 +675,30 @@ func main() {
 	fmt.Printf("Ginga Bias (média): %.5f\n", biasGinga)
 	fmt.Printf("LEA Bias (média):   %.5f\n", biasLEA)
 	fmt.Printf("Speck Bias (média): %.5f\n", biasSpeck)
+	record("Linear/AES", linearBiasPValue(biasAES, test.Trials, iterations))
+	record("Linear/Ginga", linearBiasPValue(biasGinga, test.Trials, iterations))
+	record("Linear/LEA", linearBiasPValue(biasLEA, test.Trials, iterations))
+	record("Linear/Speck", linearBiasPValue(biasSpeck, test.Trials, iterations))
 
 	// Diferencial
 	fmt.Println("\n== Criptoanálise Diferencial ==")
 	delta := make([]byte, 16)
 	delta[15] = 0x01
 	fmt.Println("AES:")
-	printTopDeltas(DifferentialAnalysisWithIterations(AESFunc, delta, 10000, iterations))
+	printTopDeltas("AES", DifferentialAnalysisWithIterations(AESFunc, delta, 10000, iterations), 5)
 	fmt.Println("Ginga:")
-	printTopDeltas(DifferentialAnalysisWithIterations(GingaFunc, delta, 10000, iterations))
+	printTopDeltas("Ginga", DifferentialAnalysisWithIterations(GingaFunc, delta, 10000, iterations), 5)
 	fmt.Println("LEA:")
-	printTopDeltas(DifferentialAnalysisWithIterations(LEAFunc, delta, 10000, iterations))
+	printTopDeltas("LEA", DifferentialAnalysisWithIterations(LEAFunc, delta, 10000, iterations), 5)
 	fmt.Println("Speck:")
-	printTopDeltas(DifferentialAnalysisWithIterations(SpeckFunc, delta, 10000, iterations))
+	printTopDeltas("Speck", DifferentialAnalysisWithIterations(SpeckFunc, delta, 10000, iterations), 5)
+
+	// Todas as diferenças de 1 bit no plaintext
+	sweep := SingleBitDeltas(16)
+	DifferentialSweep("AES", AESFunc, sweep, 2000, 5)
+	DifferentialSweep("Ginga", GingaFunc, sweep, 2000, 5)
+	DifferentialSweep("LEA", LEAFunc, sweep, 2000, 5)
+	DifferentialSweep("Speck", SpeckFunc, sweep, 2000, 5)
 
 	// Blocos Parciais
 	fmt.Println("\n== Testes com blocos incompletos ==")
@@ -632,9 +749,97 @@ func main() {
 	testGlobalAvalanchePlain("Ginga", GingaEncrypt, gingaKey, 16)
 	testGlobalAvalanchePlain("LEA", LEAEncrypt, leaKey, 16)
 	testGlobalAvalanchePlain("Speck", SpeckEncrypt, speckKey, 16)
+
+	fmt.Println("\n== SAC / BIC ==")
+	for _, input := range []string{"plaintext", "key"} {
+		testSAC("AES", AESEncrypt, 16, len(aesKey), input, 1000)
+		testSAC("Ginga", GingaEncrypt, 16, len(gingaKey), input, 1000)
+		testSAC("LEA", LEAEncrypt, 16, len(leaKey), input, 1000)
+		testSAC("Speck", SpeckEncrypt, 16, len(speckKey), input, 1000)
+
+		testBIC("AES", AESEncrypt, 16, len(aesKey), input, 1000)
+		testBIC("Ginga", GingaEncrypt, 16, len(gingaKey), input, 1000)
+		testBIC("LEA", LEAEncrypt, 16, len(leaKey), input, 1000)
+		testBIC("Speck", SpeckEncrypt, 16, len(speckKey), input, 1000)
+	}
+
+	fmt.Println("\n== Escalonamento de Chave ==")
+	testKeyAvalanche("AES", AESEncrypt, 16, len(aesKey), 1000)
+	testKeyAvalanche("Ginga", GingaEncrypt, 16, len(gingaKey), 1000)
+	testKeyAvalanche("LEA", LEAEncrypt, 16, len(leaKey), 1000)
+	testKeyAvalanche("Speck", SpeckEncrypt, 16, len(speckKey), 1000)
+
+	testRelatedKey("AES", AESEncrypt, 16, len(aesKey), SingleBitDeltas(len(aesKey)), 1000)
+	testRelatedKey("Ginga", GingaEncrypt, 16, len(gingaKey), SingleBitDeltas(len(gingaKey)), 1000)
+	testRelatedKey("LEA", LEAEncrypt, 16, len(leaKey), SingleBitDeltas(len(leaKey)), 1000)
+	testRelatedKey("Speck", SpeckEncrypt, 16, len(speckKey), SingleBitDeltas(len(speckKey)), 1000)
+
+	testRelatedKeyRounds(SingleBitDeltas(len(gingaKey)), 500)
+	testKeyDiffusionRounds(1000)
+	testWeakKeys(8, 4096)
+
+	fmt.Println("\n== Trilhas Diferenciais ==")
+	testDiffTrails()
+
+	fmt.Println("\n== Trilhas Lineares ==")
+	testLinearTrails()
+
+	fmt.Println("\n== Análise Rotacional ==")
+	testRotational(1<<16, 4096)
+
+	fmt.Println("\n== Deslizamento ==")
+	testSlide(4, 256)
+
+	fmt.Println("\n== Integral / Divisão ==")
+	testIntegral(8, 20)
+
+	fmt.Println("\n== Bumerangue ==")
+	testBCT(4)
+	testBoomerangRounds(8, 1<<18, *trailBudget)
+
+	fmt.Println("\n== NIST SP 800-22 ==")
+	testNIST()
+
+	fmt.Println("\n== Tempo Constante ==")
+	testConstantTime(*dudectSamples)
+
+	fmt.Println("\n== Desempenho ==")
+	testThroughput()
+
+	fmt.Println("\n== AEAD ==")
+	testAEAD()
+
+	fmt.Println("\n== Key Wrap ==")
+	testKeyWrap()
+
+	fmt.Println("\n== Ajuste (Tweak) ==")
+	testTweak(1000, 1000)
+
+	fmt.Println("\n== FPE ==")
+	testFPE()
+
+	fmt.Println("\n== HCTR2 ==")
+	testHCTR2()
+
+	fmt.Println("\n== Canal Seguro ==")
+	testGingaConn()
+
+	fmt.Println("\n== Noise ==")
+	testNoise()
+
+	fmt.Println("\n== Arquivo Cifrado ==")
+	testGingaFile()
+
+	fmt.Println("\n== JOSE ==")
+	testJOSE()
+
+	fmt.Println("\n== Dispositivo Cifrado ==")
+	testGingaDisk()
 	
 	testWalshSpectrum("AES", AESFunc, 16)
 	testWalshSpectrum("Ginga", GingaFunc, 16)
 	testWalshSpectrum("LEA", LEAFunc, 16)
 	testWalshSpectrum("Speck", SpeckFunc, 16)
+
+	printVerdicts()
 }
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ BUSCA DE TRILHAS DIFERENCIAIS ============
+//
+// Modelo XOR-diferencial de uma rodada de Ginga, palavra a palavra:
+//
+//	x + k          adição modular com subchave (Lipmaa–Moriai, ΔK = 0)
+//	x ^ 0xA5A5A5A5 XOR com constante (transparente)
+//	x + 0x3C3C3C3C adição com constante (probabilidade exata por cadeia de carries)
+//	rotações, ^k   lineares (determinísticas)
+//
+// seguido de mixState32, também linear. As subchaves são tratadas como
+// independentes e uniformes (hipótese de Markov). A busca é a de Matsui:
+// limites B(s, k) para k rodadas a partir da rodada s alimentam a poda das
+// buscas mais longas. O mesmo motor é usado pela busca linear (linear.go),
+// que só troca o modelo de cada palavra e da camada linear.
+
+var (
+	trailRounds = flag.Int("trail-rounds", ginga.Rounds, "número máximo de rodadas na busca de trilhas")
+	trailBudget = flag.Duration("trail-budget", 5*time.Second, "tempo máximo de cada busca de trilha")
+)
+
+const (
+	confuseConst = 0x3C3C3C3C
+	weightEps    = 1e-9
+)
+
+// TrailRound guarda as diferenças (ou máscaras) de uma rodada da trilha.
+type TrailRound struct {
+	In, AfterKey, AfterConst, Out [4]uint32
+	Weight                        float64
+}
+
+// Trail é uma característica (diferencial ou linear) sobre rodadas
+// consecutivas; Weight é -log2 da probabilidade ou da correlação.
+type Trail struct {
+	Start  int
+	Rounds []TrailRound
+	Weight float64
+}
+
+// addConstStep avança a cadeia de carries (c, c') de um bit da adição com a
+// constante c, dada a diferença de entrada g do bit.
+func addConstStep(mass [4]float64, cj, gj uint32) [4]float64 {
+	var next [4]float64
+	for s, m := range mass {
+		if m == 0 {
+			continue
+		}
+		carry, carry2 := uint32(s&1), uint32(s>>1)
+		for x := uint32(0); x < 2; x++ {
+			nc := (x & cj) | (x & carry) | (cj & carry)
+			x2 := x ^ gj
+			nc2 := (x2 & cj) | (x2 & carry2) | (cj & carry2)
+			next[nc|nc2<<1] += m / 2
+		}
+	}
+	return next
+}
+
+// enumAddKey enumera, bit a bit, as transições α → γ da adição com subchave
+// de peso <= budget. Se free, α também é escolhido (primeira rodada). A
+// enumeração para quando emit devolve false.
+func enumAddKey(alpha uint32, free bool, budget float64, emit func(a, g uint32, w int) bool) bool {
+	var rec func(j int, a, g uint32, w int) bool
+	rec = func(j int, a, g uint32, w int) bool {
+		if j == 32 {
+			return emit(a, g, w)
+		}
+		prevZero := j == 0 || ((a|g)>>(j-1))&1 == 0
+		for aj := uint32(0); aj < 2; aj++ {
+			if !free && aj != (alpha>>j)&1 {
+				continue
+			}
+			for gj := uint32(0); gj < 2; gj++ {
+				if prevZero && gj != aj {
+					continue
+				}
+				cost := 0
+				if j < 31 && aj|gj == 1 {
+					cost = 1
+				}
+				if float64(w+cost) > budget+weightEps {
+					continue
+				}
+				if !rec(j+1, a|aj<<j, g|gj<<j, w+cost) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return rec(0, 0, 0, 0)
+}
+
+// enumAddConst enumera as transições γ → δ da adição com c de peso <= budget.
+func enumAddConst(gamma, c uint32, budget float64, emit func(d uint32, w float64) bool) bool {
+	var rec func(j int, d uint32, mass [4]float64) bool
+	rec = func(j int, d uint32, mass [4]float64) bool {
+		gj := (gamma >> j) & 1
+		for dj := uint32(0); dj < 2; dj++ {
+			m := mass
+			total := 0.0
+			for s := range m {
+				if uint32(s&1)^uint32(s>>1) != gj^dj {
+					m[s] = 0
+				}
+				total += m[s]
+			}
+			if total == 0 {
+				continue
+			}
+			w := -math.Log2(total)
+			if w > budget+weightEps {
+				continue
+			}
+			if j == 31 {
+				if !emit(d|dj<<31, w) {
+					return false
+				}
+				continue
+			}
+			if !rec(j+1, d|dj<<j, addConstStep(m, (c>>j)&1, gj)) {
+				return false
+			}
+		}
+		return true
+	}
+	return rec(0, 0, [4]float64{1, 0, 0, 0})
+}
+
+// linearDiff propaga as diferenças pela parte linear da rodada r.
+func linearDiff(d [4]uint32, r int) [4]uint32 {
+	for i := range d {
+		d[i] = gRotl(d[i], 7+((r+3)&31)+((r+5)&31))
+	}
+	gMix(&d)
+	return d
+}
+
+// wordTrans é a transição de uma palavra por uma rodada: α → γ pela adição
+// com subchave e γ → δ pela adição com constante.
+type wordTrans struct {
+	a, g, d uint32
+	w       float64
+}
+
+// transList guarda as transições de peso <= cap ordenadas por peso.
+type transList struct {
+	cap  float64
+	list []wordTrans
+}
+
+const (
+	maxCachedInputs = 1 << 18
+	maxFirstRound   = 1 << 22
+)
+
+// trailModel descreve a propagação por uma rodada. enumWord enumera as
+// transições de uma palavra com peso <= budget (α livre se free) até emit
+// devolver false, e linear propaga pela parte linear da rodada r. A parte
+// não linear é igual em todas as rodadas, então o cache por α é
+// compartilhado entre as buscas.
+type trailModel struct {
+	enumWord func(alpha uint32, free bool, budget float64, emit func(a, g, d uint32, w float64) bool) bool
+	linear   func(d [4]uint32, r int) [4]uint32
+	cache    map[uint32]*transList
+}
+
+// transitions devolve as transições de α com peso <= budget (a lista pode
+// conter pesos maiores; quem itera interrompe ao ultrapassar).
+func (m *trailModel) transitions(alpha uint32, budget float64) []wordTrans {
+	if t := m.cache[alpha]; t != nil && t.cap >= budget {
+		return t.list
+	}
+	if m.cache == nil || len(m.cache) >= maxCachedInputs {
+		m.cache = map[uint32]*transList{}
+	}
+	t := &transList{cap: math.Ceil(budget)}
+	m.enumWord(alpha, false, t.cap, func(a, g, d uint32, w float64) bool {
+		t.list = append(t.list, wordTrans{a: a, g: g, d: d, w: w})
+		return true
+	})
+	sort.Slice(t.list, func(i, j int) bool { return t.list[i].w < t.list[j].w })
+	m.cache[alpha] = t
+	return t.list
+}
+
+// minWeight devolve o menor peso de uma transição de α, se for <= budget,
+// aumentando o limite da enumeração de uma unidade por vez.
+func (m *trailModel) minWeight(alpha uint32, budget float64) (float64, bool) {
+	for c := 0.0; c <= math.Ceil(budget); c++ {
+		if l := m.transitions(alpha, c); len(l) > 0 && l[0].w <= c+weightEps {
+			return l[0].w, l[0].w <= budget+weightEps
+		}
+	}
+	return 0, false
+}
+
+// firstRound lista as transições com α livre (primeira rodada); ok é falso
+// se a lista excederia maxFirstRound entradas ou o prazo terminaria antes.
+func (m *trailModel) firstRound(budget float64, deadline time.Time) (list []wordTrans, ok bool) {
+	ok = m.enumWord(0, true, budget, func(a, g, d uint32, w float64) bool {
+		if len(list) >= maxFirstRound || (len(list)&0x3FF == 0 && time.Now().After(deadline)) {
+			return false
+		}
+		list = append(list, wordTrans{a: a, g: g, d: d, w: w})
+		return true
+	})
+	if !ok {
+		return nil, false
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].w < list[j].w })
+	return list, true
+}
+
+// diffModel é o modelo XOR-diferencial descrito acima.
+var diffModel = &trailModel{
+	enumWord: func(alpha uint32, free bool, budget float64, emit func(a, g, d uint32, w float64) bool) bool {
+		return enumAddKey(alpha, free, budget, func(a, g uint32, wk int) bool {
+			return enumAddConst(g, confuseConst, budget-float64(wk), func(d uint32, wc float64) bool {
+				return emit(a, g, d, float64(wk)+wc)
+			})
+		})
+	},
+	linear: linearDiff,
+}
+
+// trailSearch é o estado de uma busca de Matsui para k rodadas a partir de s.
+type trailSearch struct {
+	model         *trailModel
+	start, rounds int
+	fixedIn       *[4]uint32
+	bounds        [][]float64
+	bound         float64
+	first         []wordTrans
+	cur, best     []TrailRound
+	bestWeight    float64
+	deadline      time.Time
+	nodes         int
+	aborted       bool
+}
+
+func (s *trailSearch) lowerBound(start, k int) float64 {
+	if k <= 0 {
+		return 0
+	}
+	return s.bounds[start][k]
+}
+
+func (s *trailSearch) round(ri int, in [4]uint32, w float64) {
+	if s.aborted {
+		return
+	}
+	if s.nodes++; s.nodes&0xFFF == 0 && time.Now().After(s.deadline) {
+		s.aborted = true
+		return
+	}
+	if ri == s.rounds {
+		if w < s.bestWeight {
+			s.bestWeight = w
+			s.best = append(s.best[:0], s.cur...)
+			s.bound = w
+		}
+		return
+	}
+	rem := s.lowerBound(s.start+ri+1, s.rounds-ri-1)
+
+	var lists [4][]wordTrans
+	var minRest [5]float64
+	if ri == 0 && s.fixedIn == nil {
+		for i := range lists {
+			lists[i] = s.first
+		}
+	} else {
+		// Peso mínimo de cada palavra: poda as palavras seguintes e limita a
+		// enumeração de cada uma ao que sobra depois das demais.
+		var minW [4]float64
+		avail, total := s.bound-w-rem, 0.0
+		for i := range lists {
+			var ok bool
+			if minW[i], ok = s.model.minWeight(in[i], avail); !ok {
+				return
+			}
+			total += minW[i]
+		}
+		if total > avail+weightEps {
+			return
+		}
+		for i := range lists {
+			lists[i] = s.model.transitions(in[i], avail-total+minW[i])
+		}
+		for i := 3; i >= 0; i-- {
+			minRest[i] = minRest[i+1] + minW[i]
+		}
+	}
+
+	var r TrailRound
+	r.In = in
+	s.word(ri, 0, &r, &lists, &minRest, w, w, rem)
+}
+
+// word escolhe a transição da palavra i; w0 é o peso acumulado no início
+// da rodada e rem o limite inferior das rodadas restantes.
+func (s *trailSearch) word(ri, i int, r *TrailRound, lists *[4][]wordTrans, minRest *[5]float64, w, w0, rem float64) {
+	if s.aborted {
+		return
+	}
+	if i == 4 {
+		if r.In == [4]uint32{} {
+			return
+		}
+		r.Out = s.model.linear(r.AfterConst, s.start+ri)
+		r.Weight = w - w0
+		s.cur = append(s.cur[:ri], *r)
+		s.round(ri+1, r.Out, w)
+		return
+	}
+
+	for _, t := range lists[i] {
+		if w+t.w+minRest[i+1]+rem > s.bound+weightEps {
+			break
+		}
+		r.In[i], r.AfterKey[i], r.AfterConst[i] = t.a, t.g, t.d
+		s.word(ri, i+1, r, lists, minRest, w+t.w, w0, rem)
+		if s.aborted {
+			return
+		}
+	}
+}
+
+// searchTrail devolve a melhor trilha de peso <= limit (ou nil) e se a
+// busca foi exaustiva.
+func searchTrail(m *trailModel, start, rounds int, bounds [][]float64, fixedIn *[4]uint32, limit float64, budget time.Duration) (*Trail, bool) {
+	s := &trailSearch{
+		model:      m,
+		start:      start,
+		rounds:     rounds,
+		fixedIn:    fixedIn,
+		bounds:     bounds,
+		bound:      limit,
+		bestWeight: math.Inf(1),
+		deadline:   time.Now().Add(budget),
+	}
+	in := [4]uint32{}
+	if fixedIn != nil {
+		in = *fixedIn
+	} else {
+		var ok bool
+		s.first, ok = m.firstRound(limit-s.lowerBound(start+1, rounds-1), s.deadline)
+		if !ok {
+			return nil, false
+		}
+	}
+	s.round(0, in, 0)
+	if s.best == nil {
+		return nil, !s.aborted
+	}
+	return &Trail{Start: start, Rounds: s.best, Weight: s.bestWeight}, !s.aborted
+}
+
+// BestTrails calcula, para k = 1..maxRounds, a melhor trilha encontrada
+// a partir da rodada 0 e o limite inferior provado para o seu peso.
+func BestTrails(m *trailModel, maxRounds int, budget time.Duration) ([]*Trail, []float64) {
+	trails, bounds := trailTable(m, maxRounds, budget)
+	best := make([]*Trail, maxRounds+1)
+	lbs := make([]float64, maxRounds+1)
+	for k := 1; k <= maxRounds; k++ {
+		best[k], lbs[k] = trails[0][k], bounds[0][k]
+	}
+	return best, lbs
+}
+
+// trailTable calcula trails[s][k] e bounds[s][k] para toda rodada inicial s
+// e k = 1..maxRounds.
+func trailTable(m *trailModel, maxRounds int, budget time.Duration) (trails [][]*Trail, bounds [][]float64) {
+	n := ginga.Rounds
+	bounds = make([][]float64, n+1)
+	for i := range bounds {
+		bounds[i] = make([]float64, n+1)
+	}
+	trails = make([][]*Trail, n+1)
+	for i := range trails {
+		trails[i] = make([]*Trail, n+1)
+	}
+
+	for k := 1; k <= maxRounds; k++ {
+		for st := 0; st+k <= n; st++ {
+			// Limite inferior inicial pela decomposição em blocos menores.
+			lb := 0.0
+			for i := 1; i < k; i++ {
+				lb = math.Max(lb, bounds[st][i]+bounds[st+i][k-i])
+			}
+
+			deadline := time.Now().Add(budget)
+			var found *Trail
+			for t := math.Floor(lb) + 1; time.Now().Before(deadline); t++ {
+				tr, done := searchTrail(m, st, k, bounds, nil, t+weightEps, time.Until(deadline))
+				if tr != nil {
+					found = tr
+					if done {
+						lb = tr.Weight
+					}
+					break
+				}
+				if !done {
+					break
+				}
+				lb = t
+			}
+
+			// Sem trilha no prazo: estende gulosamente a melhor de k-1 rodadas.
+			if found == nil && k > 1 && trails[st][k-1] != nil {
+				found = extendTrail(m, trails[st][k-1], bounds, budget)
+			}
+
+			bounds[st][k] = lb
+			trails[st][k] = found
+		}
+	}
+	return trails, bounds
+}
+
+// extendTrail acrescenta à trilha a rodada seguinte de menor peso,
+// fornecendo um limite superior quando a busca completa não termina.
+func extendTrail(m *trailModel, prev *Trail, bounds [][]float64, budget time.Duration) *Trail {
+	last := prev.Rounds[len(prev.Rounds)-1].Out
+	deadline := time.Now().Add(budget)
+	for t := 1.0; t <= 4*2*32 && time.Now().Before(deadline); t++ {
+		ext, _ := searchTrail(m, prev.Start+len(prev.Rounds), 1, bounds, &last, t+weightEps, time.Until(deadline))
+		if ext != nil {
+			rounds := append(append([]TrailRound(nil), prev.Rounds...), ext.Rounds...)
+			return &Trail{Start: prev.Start, Rounds: rounds, Weight: prev.Weight + ext.Weight}
+		}
+	}
+	return nil
+}
+
+func printTrail(t *Trail) {
+	for i, r := range t.Rounds {
+		fmt.Printf("  R%-2d %08x %08x %08x %08x → %08x %08x %08x %08x  (2^-%.2f)\n",
+			t.Start+i+1, r.In[0], r.In[1], r.In[2], r.In[3], r.Out[0], r.Out[1], r.Out[2], r.Out[3], r.Weight)
+	}
+}
+
+// validateDiffTrail estima empiricamente a probabilidade do diferencial
+// (entrada → saída da trilha) em Ginga reduzida, com chaves aleatórias.
+func validateDiffTrail(t *Trail, samples int) {
+	in := t.Rounds[0].In
+	out := t.Rounds[len(t.Rounds)-1].Out
+	hits := 0
+	for n := 0; n < samples; n++ {
+		k := loadKey(randomBytes(32))
+		x := loadState(randomBytes(16))
+		var x2 [4]uint32
+		for i := range x {
+			x2[i] = x[i] ^ in[i]
+		}
+		y := encryptWords(x, &k, t.Start, t.Start+len(t.Rounds))
+		y2 := encryptWords(x2, &k, t.Start, t.Start+len(t.Rounds))
+		if y[0]^y2[0] == out[0] && y[1]^y2[1] == out[1] && y[2]^y2[2] == out[2] && y[3]^y2[3] == out[3] {
+			hits++
+		}
+	}
+	lo, hi := wilsonInterval(hits, samples, *alpha)
+	fmt.Printf("  Empírico: %d/%d = 2^%.2f [%.3g, %.3g] (trilha: 2^-%.2f)\n",
+		hits, samples, math.Log2(float64(hits)/float64(samples)), lo, hi, t.Weight)
+}
+
+func testDiffTrails() {
+	fmt.Printf("\n🧭 Busca de trilhas diferenciais (Ginga, até %d rodadas, %v por busca):\n", *trailRounds, *trailBudget)
+
+	trails, lbs := BestTrails(diffModel, *trailRounds, *trailBudget)
+	for k := 1; k < len(trails); k++ {
+		t := trails[k]
+		if t == nil {
+			fmt.Printf("Rodadas %2d: nenhuma trilha no prazo, peso >= %.2f\n", k, lbs[k])
+			continue
+		}
+		status := "ótima"
+		if t.Weight > lbs[k]+weightEps {
+			status = fmt.Sprintf("limite inferior %.2f", lbs[k])
+		}
+		fmt.Printf("Rodadas %2d: peso %.2f (p = 2^-%.2f, %s)\n", k, t.Weight, t.Weight, status)
+		printTrail(t)
+		if t.Weight <= 16 {
+			validateDiffTrail(t, 1<<20)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// ============ GINGA COM AJUSTE (TWEAK) ============
+
+// tweakInterval repete a constante de ginga.TweakableBlock: o ajuste entra
+// nas rodadas múltiplas de 4.
+const tweakInterval = 4
+
+// encryptTweakWords é encryptWords com o ajuste somado às subchaves nas
+// rodadas de injeção.
+func encryptTweakWords(s [4]uint32, k *[8]uint32, tw [4]uint32, rounds int) [4]uint32 {
+	for r := 0; r < rounds; r++ {
+		for i := 0; i < 4; i++ {
+			sk := gSubKey(k, r, i)
+			if r%tweakInterval == 0 {
+				j := r / tweakInterval
+				sk ^= gRotl(tw[(i+j)&3], 8*j)
+			}
+			s[i] = gRound(s[i], sk, r)
+		}
+		gMix(&s)
+	}
+	return s
+}
+
+// GingaTweakRounds devolve uma função (bloco, ajuste) de Ginga com ajuste,
+// reduzida a rounds rodadas, sob uma chave fixa. O ajuste ocupa o lugar da
+// chave para reaproveitar as análises de chave relacionada e de avalanche.
+func GingaTweakRounds(key []byte, rounds int) func([]byte, []byte) ([]byte, error) {
+	k := loadKey(key)
+	return func(pt, tweak []byte) ([]byte, error) {
+		return storeState(encryptTweakWords(loadState(pt), &k, loadState(tweak), rounds)), nil
+	}
+}
+
+// checkTweakable confere a cópia acima contra ginga.TweakableBlock, o ajuste
+// zero contra ginga.Encrypt e Decrypt contra Encrypt.
+func checkTweakable() {
+	key, pt, tweak := randomBytes(32), randomBytes(16), randomBytes(16)
+	tb, err := ginga.NewTweakable(key)
+	if err != nil {
+		panic(err)
+	}
+	ct := make([]byte, 16)
+	tb.Encrypt(ct, pt, tweak)
+	want, _ := GingaTweakRounds(key, ginga.Rounds)(pt, tweak)
+	if !bytes.Equal(ct, want) {
+		panic("Ginga com ajuste diverge da implementação de referência")
+	}
+	tb.Decrypt(ct, ct, tweak)
+	if !bytes.Equal(ct, pt) {
+		panic("TweakableBlock.Decrypt não inverte Encrypt")
+	}
+	tb.Encrypt(ct, pt, make([]byte, 16))
+	if want, _ := ginga.Encrypt(pt, key); !bytes.Equal(ct, want) {
+		panic("TweakableBlock com ajuste zero diverge de ginga.Encrypt")
+	}
+}
+
+// testRelatedTweakRounds repete o experimento de ajuste relacionado em
+// Ginga reduzida, como testRelatedKeyRounds faz para a chave.
+func testRelatedTweakRounds(deltas [][]byte, trials int) {
+	fmt.Printf("\n🪡 Ajustes Relacionados por rodada (Ginga): %d ΔT × %d amostras\n", len(deltas), trials)
+
+	key := randomBytes(32)
+	for r := 1; r <= ginga.Rounds; r++ {
+		best := 0
+		var bestDelta []byte
+		for _, dt := range deltas {
+			top := TopDeltas(RelatedKeyAnalysis(GingaTweakRounds(key, r), 16, 16, dt, trials), 1)
+			if len(top) > 0 && top[0].Count > best {
+				best, bestDelta = top[0].Count, dt
+			}
+		}
+		p := math.Min(1, differentialPValue(best, trials, 16)*float64(len(deltas)))
+		mark := ""
+		if r%tweakInterval == 0 && r < ginga.Rounds {
+			mark = " (injeção na próxima rodada)"
+		}
+		fmt.Printf("Rodadas %2d: melhor ΔT %x → %d/%d (p = %.3g)%s\n", r, bestDelta, best, trials, p, mark)
+	}
+}
+
+// testTweak reúne os ganchos de análise de TweakableBlock: o ajuste ocupa o
+// lugar da chave em testKeyAvalanche e testRelatedKey.
+func testTweak(samples, trials int) {
+	checkTweakable()
+	full := GingaTweakRounds(randomBytes(32), ginga.Rounds)
+	testKeyAvalanche("Ginga-ajuste", full, 16, ginga.TweakSize, samples)
+	testRelatedKey("Ginga-ajuste", full, 16, ginga.TweakSize, SingleBitDeltas(ginga.TweakSize), trials)
+	testRelatedTweakRounds(SingleBitDeltas(ginga.TweakSize), trials/2)
+}
@@ -0,0 +1,149 @@
+package ginga
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// --- CTR ---
+
+// ErrCounterOverflow indica que o contador de 128 bits daria a volta: o
+// fluxo de chave a partir daí repetiria o do início.
+var ErrCounterOverflow = errors.New("ginga: CTR counter overflow")
+
+// CTR é o modo contador com o mesmo leiaute de ginga_ctr_crypt em
+// c/ginga.c: o bloco n usa o IV somado a n como inteiro big-endian de 16
+// bytes. O fluxo de chave é gerado em lotes de vários blocos, a posição pode
+// ser alterada com Seek e o contador nunca dá a volta.
+type CTR struct {
+	b       cipher.Block
+	iv      [BlockSize]byte
+	counter [BlockSize]byte // próximo bloco a gerar
+	wrapped bool            // o último bloco gerado foi o de contador FF..FF
+	wrap    bool            // permite dar a volta (EAX define o contador módulo 2^128)
+	ks      [batch * BlockSize]byte
+	used    int // bytes de ks já consumidos
+	end     int // bytes válidos em ks
+	pos     int64
+}
+
+// NewCTR devolve um CTR que cifra vários blocos por vez quando b é uma cifra
+// Ginga.
+func NewCTR(b cipher.Block, iv []byte) *CTR {
+	if b.BlockSize() != BlockSize {
+		panic("ginga: CTR requires a 16-byte block cipher")
+	}
+	if len(iv) != BlockSize {
+		panic("ginga: IV length must equal block size")
+	}
+	s := &CTR{b: b}
+	copy(s.iv[:], iv)
+	s.counter = s.iv
+	return s
+}
+
+// incCounter incrementa o contador e informa se ele deu a volta.
+func incCounter(c *[BlockSize]byte) bool {
+	for i := BlockSize - 1; i >= 0; i-- {
+		c[i]++
+		if c[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *CTR) refill() {
+	n := 0
+	for ; n < batch && !s.wrapped; n++ {
+		copy(s.ks[n*BlockSize:], s.counter[:])
+		s.wrapped = incCounter(&s.counter) && !s.wrap
+	}
+	encryptBlocks(s.b, s.ks[:n*BlockSize], s.ks[:n*BlockSize])
+	s.used, s.end = 0, n*BlockSize
+}
+
+// fits informa se ainda há fluxo de chave para n bytes.
+func (s *CTR) fits(n int) bool {
+	buffered := s.end - s.used
+	if n <= buffered || s.wrap {
+		return true
+	}
+	if s.wrapped {
+		return false
+	}
+	// Blocos restantes: 2^128 - contador, que só é pequeno quando a metade
+	// alta é toda de uns.
+	if binary.BigEndian.Uint64(s.counter[:8]) != ^uint64(0) {
+		return true
+	}
+	lo := binary.BigEndian.Uint64(s.counter[8:])
+	need := uint64(n-buffered+BlockSize-1) / BlockSize
+	return lo == 0 || need <= -lo
+}
+
+// Crypt cifra (ou decifra) src em dst. Se o contador não comportar
+// len(src) bytes, nada é processado e o erro é ErrCounterOverflow.
+func (s *CTR) Crypt(dst, src []byte) error {
+	if len(dst) < len(src) {
+		panic("ginga: output smaller than input")
+	}
+	if !s.fits(len(src)) {
+		return ErrCounterOverflow
+	}
+	s.pos += int64(len(src))
+	for len(src) > 0 {
+		if s.used == s.end {
+			s.refill()
+		}
+		n := subtle.XORBytes(dst, src, s.ks[s.used:s.end])
+		s.used += n
+		src, dst = src[n:], dst[n:]
+	}
+	return nil
+}
+
+// XORKeyStream implementa cipher.Stream; entra em pânico com
+// ErrCounterOverflow onde Crypt devolveria o erro.
+func (s *CTR) XORKeyStream(dst, src []byte) {
+	if err := s.Crypt(dst, src); err != nil {
+		panic(err)
+	}
+}
+
+// Seek implementa io.Seeker sobre a posição em bytes a partir do IV, para
+// decifrar trechos arbitrários. io.SeekEnd não é suportado.
+func (s *CTR) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = s.pos + offset
+	default:
+		return 0, errors.New("ginga: CTR seek supports only io.SeekStart and io.SeekCurrent")
+	}
+	if abs < 0 {
+		return 0, errors.New("ginga: CTR seek to negative position")
+	}
+
+	hi := binary.BigEndian.Uint64(s.iv[:8])
+	lo := binary.BigEndian.Uint64(s.iv[8:])
+	lo, carry := bits.Add64(lo, uint64(abs/BlockSize), 0)
+	hi, carry = bits.Add64(hi, 0, carry)
+	if carry != 0 {
+		return 0, ErrCounterOverflow
+	}
+	binary.BigEndian.PutUint64(s.counter[:8], hi)
+	binary.BigEndian.PutUint64(s.counter[8:], lo)
+	s.wrapped, s.used, s.end, s.pos = false, 0, 0, abs
+	if r := int(abs % BlockSize); r != 0 {
+		s.refill()
+		s.used = r
+	}
+	return abs, nil
+}
@@ -0,0 +1,117 @@
+package ginga
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+// --- EAX ---
+
+type eax struct {
+	b         cipher.Block
+	nonceSize int
+	tagSize   int
+	k1, k2    [BlockSize]byte // subchaves do CMAC
+}
+
+// NewEAX devolve EAX (Bellare, Rogaway e Wagner) sobre um bloco de 16 bytes:
+// CTR para cifrar e OMAC (CMAC) para autenticar, em duas passadas. Aceita
+// nonce de qualquer tamanho positivo e tag de 1 a 16 bytes.
+func NewEAX(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != BlockSize {
+		return nil, errors.New("ginga: EAX requires a 16-byte block cipher")
+	}
+	if nonceSize < 1 {
+		return nil, errors.New("ginga: EAX nonce size must be positive")
+	}
+	if tagSize < 1 || tagSize > BlockSize {
+		return nil, errors.New("ginga: EAX tag size must be between 1 and 16 bytes")
+	}
+	e := &eax{b: b, nonceSize: nonceSize, tagSize: tagSize}
+	var l [BlockSize]byte
+	b.Encrypt(l[:], l[:])
+	e.k1 = double(l)
+	e.k2 = double(e.k1)
+	return e, nil
+}
+
+func (e *eax) NonceSize() int { return e.nonceSize }
+func (e *eax) Overhead() int  { return e.tagSize }
+
+// omac calcula OMAC^t(m) = CMAC([t]_16 || m).
+func (e *eax) omac(t byte, m []byte) [BlockSize]byte {
+	var mac [BlockSize]byte
+	mac[BlockSize-1] = t
+	if len(m) == 0 {
+		// [t] é o último bloco, completo.
+		xorBlock(&mac, mac[:], e.k1[:])
+		e.b.Encrypt(mac[:], mac[:])
+		return mac
+	}
+	e.b.Encrypt(mac[:], mac[:])
+	for len(m) > BlockSize {
+		xorBlock(&mac, mac[:], m)
+		e.b.Encrypt(mac[:], mac[:])
+		m = m[BlockSize:]
+	}
+	if len(m) == BlockSize {
+		xorBlock(&mac, mac[:], m)
+		xorBlock(&mac, mac[:], e.k1[:])
+	} else {
+		subtle.XORBytes(mac[:len(m)], mac[:len(m)], m)
+		mac[len(m)] ^= 0x80
+		xorBlock(&mac, mac[:], e.k2[:])
+	}
+	e.b.Encrypt(mac[:], mac[:])
+	return mac
+}
+
+// ctr cifra src com o contador iniciado em n, dando a volta módulo 2^128
+// como especifica o EAX.
+func (e *eax) ctr(dst, src []byte, n [BlockSize]byte) {
+	s := NewCTR(e.b, n[:])
+	s.wrap = true
+	s.XORKeyStream(dst, src)
+}
+
+// tag combina N', H' e C' em uma tag de 16 bytes.
+func (e *eax) tag(n [BlockSize]byte, ad, ciphertext []byte) [BlockSize]byte {
+	h := e.omac(1, ad)
+	c := e.omac(2, ciphertext)
+	xorBlock(&n, n[:], h[:])
+	xorBlock(&n, n[:], c[:])
+	return n
+}
+
+func (e *eax) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != e.nonceSize {
+		panic("ginga: incorrect nonce length given to EAX")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+e.tagSize)
+	n := e.omac(0, nonce)
+	e.ctr(out, plaintext, n)
+	t := e.tag(n, additionalData, out[:len(plaintext)])
+	copy(out[len(plaintext):], t[:e.tagSize])
+	return ret
+}
+
+func (e *eax) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != e.nonceSize {
+		panic("ginga: incorrect nonce length given to EAX")
+	}
+	if len(ciphertext) < e.tagSize {
+		return nil, errOpen
+	}
+	tag := ciphertext[len(ciphertext)-e.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-e.tagSize]
+
+	n := e.omac(0, nonce)
+	t := e.tag(n, additionalData, ciphertext)
+	if subtle.ConstantTimeCompare(t[:e.tagSize], tag) != 1 {
+		return nil, errOpen
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	e.ctr(out, ciphertext, n)
+	return ret, nil
+}
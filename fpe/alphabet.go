@@ -0,0 +1,113 @@
+package fpe
+
+import (
+	"crypto/cipher"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// --- Alfabetos e cadeias ---
+
+// Alfabetos comuns; a base é o número de símbolos.
+const (
+	Digits       = "0123456789"
+	HexLower     = "0123456789abcdef"
+	HexUpper     = "0123456789ABCDEF"
+	Base36       = "0123456789abcdefghijklmnopqrstuvwxyz"
+	Alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// Cipher cifra cadeias sobre um alfabeto com FF1: cada símbolo vira um
+// numeral (sua posição no alfabeto) e o resultado volta ao mesmo alfabeto,
+// com o mesmo comprimento em símbolos.
+type Cipher struct {
+	ff1     *FF1
+	symbols []rune
+	index   map[rune]uint16
+	tweak   []byte
+}
+
+// New cria um Cipher com Ginga (chave de 32 bytes) sobre alphabet, que deve
+// ter de 2 a 65536 símbolos distintos. tweak é o ajuste usado por Encrypt e
+// Decrypt e pode ser nil.
+func New(key []byte, alphabet string, tweak []byte) (*Cipher, error) {
+	b, err := ginga.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithCipher(b, alphabet, tweak)
+}
+
+// NewWithCipher é New sobre uma cifra de bloco qualquer de 16 bytes.
+func NewWithCipher(b cipher.Block, alphabet string, tweak []byte) (*Cipher, error) {
+	if !utf8.ValidString(alphabet) {
+		return nil, errors.New("fpe: alphabet is not valid UTF-8")
+	}
+	symbols := []rune(alphabet)
+	if len(symbols) < 2 || len(symbols) > 1<<16 {
+		return nil, errors.New("fpe: alphabet must have between 2 and 65536 symbols")
+	}
+	index := make(map[rune]uint16, len(symbols))
+	for i, r := range symbols {
+		if _, dup := index[r]; dup {
+			return nil, errors.New("fpe: alphabet has repeated symbols")
+		}
+		index[r] = uint16(i)
+	}
+	ff1, err := NewFF1WithCipher(b, len(symbols))
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{ff1: ff1, symbols: symbols, index: index, tweak: append([]byte(nil), tweak...)}, nil
+}
+
+// WithTweak devolve uma cópia de c que usa outro ajuste; a chave e o
+// alfabeto são compartilhados.
+func (c *Cipher) WithTweak(tweak []byte) *Cipher {
+	d := *c
+	d.tweak = append([]byte(nil), tweak...)
+	return &d
+}
+
+// FF1 devolve a rede de Feistel subjacente, para cifrar numerais
+// diretamente.
+func (c *Cipher) FF1() *FF1 { return c.ff1 }
+
+// Encrypt cifra s, que só pode conter símbolos do alfabeto e deve ter ao
+// menos FF1().MinLen() símbolos.
+func (c *Cipher) Encrypt(s string) (string, error) {
+	return c.crypt(s, false)
+}
+
+// Decrypt desfaz Encrypt.
+func (c *Cipher) Decrypt(s string) (string, error) {
+	return c.crypt(s, true)
+}
+
+func (c *Cipher) crypt(s string, decrypt bool) (string, error) {
+	x := make([]uint16, 0, len(s))
+	for _, r := range s {
+		d, ok := c.index[r]
+		if !ok {
+			return "", errors.New("fpe: input has a symbol outside the alphabet")
+		}
+		x = append(x, d)
+	}
+	var y []uint16
+	var err error
+	if decrypt {
+		y, err = c.ff1.DecryptNumerals(x, c.tweak)
+	} else {
+		y, err = c.ff1.EncryptNumerals(x, c.tweak)
+	}
+	if err != nil {
+		return "", err
+	}
+	out := make([]rune, len(y))
+	for i, d := range y {
+		out[i] = c.symbols[d]
+	}
+	return string(out), nil
+}
@@ -0,0 +1,203 @@
+// Package fpe implementa cifragem que preserva formato (FPE) no estilo FF1
+// do NIST SP 800-38G, com a cifra Ginga como função pseudoaleatória das
+// rodadas. O texto cifrado tem o mesmo alfabeto e o mesmo comprimento do
+// texto claro, o que serve para tokenizar identificadores numéricos.
+package fpe
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// --- FF1 ---
+
+// rounds é o número de rodadas da rede de Feistel do FF1.
+const rounds = 10
+
+// minDomain é o menor número de entradas possíveis aceito para uma
+// mensagem: radix^minLen ≥ 10^6 (SP 800-38G, revisão 1).
+const minDomain = 1000000
+
+// maxLen limita o comprimento da mensagem e do ajuste, que o FF1 codifica em
+// 4 bytes.
+const maxLen = 1<<32 - 1
+
+// FF1 é a rede de Feistel do FF1 sobre numerais em uma base (radix) de 2 a
+// 65536. O ajuste (tweak) pode ter qualquer tamanho e é passado a cada
+// chamada.
+type FF1 struct {
+	b      cipher.Block
+	radix  int
+	minLen int
+}
+
+// NewFF1 cria um FF1 com Ginga, a partir de uma chave de 32 bytes.
+func NewFF1(key []byte, radix int) (*FF1, error) {
+	b, err := ginga.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewFF1WithCipher(b, radix)
+}
+
+// NewFF1WithCipher cria um FF1 sobre uma cifra de bloco qualquer de 16
+// bytes; com AES, o resultado é o FF1 do NIST.
+func NewFF1WithCipher(b cipher.Block, radix int) (*FF1, error) {
+	if b.BlockSize() != 16 {
+		return nil, errors.New("fpe: FF1 requires a 16-byte block cipher")
+	}
+	if radix < 2 || radix > 1<<16 {
+		return nil, errors.New("fpe: radix must be between 2 and 65536")
+	}
+	// Em uint64 para não estourar em plataformas de 32 bits: radix² chega a
+	// 2^32, e d nunca passa de minDomain·radix.
+	minLen := 2
+	for d := uint64(radix) * uint64(radix); d < minDomain; d *= uint64(radix) {
+		minLen++
+	}
+	return &FF1{b: b, radix: radix, minLen: minLen}, nil
+}
+
+// Radix devolve a base dos numerais.
+func (f *FF1) Radix() int { return f.radix }
+
+// MinLen devolve o menor comprimento de mensagem aceito nesta base.
+func (f *FF1) MinLen() int { return f.minLen }
+
+// prf é o CBC-MAC com IV zero de FF1; len(x) é múltiplo de 16.
+func (f *FF1) prf(x []byte) [16]byte {
+	var y [16]byte
+	for ; len(x) > 0; x = x[16:] {
+		for i := range y {
+			y[i] ^= x[i]
+		}
+		f.b.Encrypt(y[:], y[:])
+	}
+	return y
+}
+
+// num é NUM_radix(x): os numerais lidos como inteiro, o mais significativo
+// primeiro.
+func (f *FF1) num(x []uint16) *big.Int {
+	n, r := new(big.Int), big.NewInt(int64(f.radix))
+	for _, d := range x {
+		n.Mul(n, r)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	return n
+}
+
+// str é STR^m_radix(n): n escrito com exatamente len(out) numerais.
+func (f *FF1) str(out []uint16, n *big.Int) {
+	r, d := big.NewInt(int64(f.radix)), new(big.Int)
+	n = new(big.Int).Set(n)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.QuoRem(n, r, d)
+		out[i] = uint16(d.Int64())
+	}
+}
+
+func (f *FF1) check(x []uint16, tweak []byte) error {
+	if len(x) < f.minLen || uint64(len(x)) > maxLen {
+		return errors.New("fpe: message length out of range for this radix")
+	}
+	if uint64(len(tweak)) > maxLen {
+		return errors.New("fpe: tweak too long")
+	}
+	for _, d := range x {
+		if int(d) >= f.radix {
+			return errors.New("fpe: numeral out of range for this radix")
+		}
+	}
+	return nil
+}
+
+// feistel executa as dez rodadas, para frente ou para trás.
+func (f *FF1) feistel(x []uint16, tweak []byte, decrypt bool) ([]uint16, error) {
+	if err := f.check(x, tweak); err != nil {
+		return nil, err
+	}
+	n, t := len(x), len(tweak)
+	u, v := n/2, n-n/2
+
+	radix := big.NewInt(int64(f.radix))
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+	// b é o número de bytes de NUM(B): ⌈⌈v·log2(radix)⌉/8⌉.
+	b := (new(big.Int).Sub(modV, big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+
+	var p [16]byte
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(f.radix>>16), byte(f.radix>>8), byte(f.radix)
+	p[6], p[7] = 10, byte(u)
+	binary.BigEndian.PutUint32(p[8:], uint32(n))
+	binary.BigEndian.PutUint32(p[12:], uint32(t))
+
+	// P || Q, com Q = T || 0^((−t−b−1) mod 16) || [i] || [NUM(B)]^b.
+	pad := ((-t-b-1)%16 + 16) % 16
+	pq := make([]byte, 16+t+pad+1+b)
+	copy(pq, p[:])
+	copy(pq[16:], tweak)
+	round, numB := pq[16+t+pad:16+t+pad+1], pq[len(pq)-b:]
+
+	s := make([]byte, (d+15)&^15)
+	a, c := append([]uint16(nil), x[:u]...), append([]uint16(nil), x[u:]...)
+	y, z := new(big.Int), new(big.Int)
+	for j := 0; j < rounds; j++ {
+		// Na ida, B alimenta a PRF e A recebe a soma; na volta, o contrário.
+		i, feed, other := j, c, a
+		if decrypt {
+			i, feed, other = rounds-1-j, a, c
+		}
+		m, mod := u, modU
+		if i%2 == 1 {
+			m, mod = v, modV
+		}
+
+		round[0] = byte(i)
+		f.num(feed).FillBytes(numB)
+		r := f.prf(pq)
+		copy(s, r[:])
+		for k := 1; 16*k < d; k++ {
+			var blk [16]byte
+			binary.BigEndian.PutUint64(blk[8:], uint64(k))
+			for w := range blk {
+				blk[w] ^= r[w]
+			}
+			f.b.Encrypt(s[16*k:], blk[:])
+		}
+		y.SetBytes(s[:d])
+
+		z.Set(f.num(other))
+		if decrypt {
+			z.Sub(z, y)
+		} else {
+			z.Add(z, y)
+		}
+		z.Mod(z, mod)
+		out := make([]uint16, m)
+		f.str(out, z)
+		if decrypt {
+			a, c = out, a
+		} else {
+			a, c = c, out
+		}
+	}
+	return append(a, c...), nil
+}
+
+// EncryptNumerals cifra x, uma sequência de numerais menores que Radix(),
+// sob o ajuste tweak. O resultado tem o mesmo comprimento.
+func (f *FF1) EncryptNumerals(x []uint16, tweak []byte) ([]uint16, error) {
+	return f.feistel(x, tweak, false)
+}
+
+// DecryptNumerals desfaz EncryptNumerals com o mesmo ajuste.
+func (f *FF1) DecryptNumerals(x []uint16, tweak []byte) ([]uint16, error) {
+	return f.feistel(x, tweak, true)
+}
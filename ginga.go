@@ -141,6 +141,7 @@ func Decrypt(ciphertext, key []byte) ([]byte, error) {
 
 type gingaCipher struct {
 	key []byte
+	sk  [Rounds][4]uint32 // subchaves, usadas por EncryptBlocks/DecryptBlocks
 }
 
 // NewCipher cria um objeto cipher.Block compatível com modos de operação
@@ -148,7 +149,7 @@ func NewCipher(key []byte) (cipher.Block, error) {
 	if len(key) != 32 {
 		return nil, errors.New("ginga: invalid key size (must be 32 bytes)")
 	}
-	return &gingaCipher{key: append([]byte(nil), key...)}, nil
+	return &gingaCipher{key: append([]byte(nil), key...), sk: schedule(key)}, nil
 }
 
 // BlockSize retorna o tamanho do bloco da cifra (16 bytes)
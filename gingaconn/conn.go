@@ -0,0 +1,349 @@
+// Package gingaconn é um canal seguro sobre net.Conn, para serviços internos
+// que conversam sem TLS: handshake autenticado com chaves X25519 efêmeras e
+// chaves estáticas fixadas (pinning), chaves de cada sentido derivadas com
+// HKDF-GingaHash e registros cifrados com Ginga-OCB3, com nonce formado pelo
+// número de sequência, troca periódica de chaves e registro de fechamento
+// autenticado.
+//
+// As chaves estáticas vão em claro no handshake, então quem observa a
+// conexão sabe quais pares conversam; o tamanho e o momento de cada registro
+// também ficam visíveis.
+package gingaconn
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pedroalbanese/ginga"
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// --- Registros ---
+
+const (
+	// MaxRecordSize é o maior texto claro levado em um registro.
+	MaxRecordSize = 1 << 14
+
+	// DefaultRekeyInterval é o número de registros, em cada sentido, entre
+	// duas trocas de chave quando Config.RekeyInterval é zero.
+	DefaultRekeyInterval = 1 << 20
+
+	nonceSize  = 12
+	tagSize    = 16
+	headerSize = 3 // tipo || tamanho (2 bytes, big-endian)
+
+	// closeNotifyTimeout limita a espera para enviar o registro de
+	// fechamento a um par que não lê mais.
+	closeNotifyTimeout = 5 * time.Second
+)
+
+// Tipos de registro. O tipo vai no cabeçalho, que é o dado associado; um
+// registro de fechamento não tem texto claro e marca o fim autenticado do
+// fluxo, de modo que cortar a conexão num limite de registro não passa por
+// um fechamento normal.
+const (
+	recordData  byte = 0
+	recordClose byte = 1
+)
+
+var (
+	errRecordAuth = errors.New("gingaconn: record authentication failed")
+	errRecordSize = errors.New("gingaconn: record too large")
+	errRecordType = errors.New("gingaconn: unknown record type")
+)
+
+// Config configura um lado da conexão.
+type Config struct {
+	// PrivateKey é a chave estática X25519 deste lado.
+	PrivateKey *ecdh.PrivateKey
+
+	// PeerKeys são as chaves estáticas aceitas do outro lado; o handshake
+	// falha se o par apresentar outra.
+	PeerKeys []*ecdh.PublicKey
+
+	// RekeyInterval é o número de registros por chave em cada sentido. Ao
+	// atingi-lo, os dois lados derivam a chave seguinte da atual, sem
+	// mensagens extras. Zero usa DefaultRekeyInterval.
+	RekeyInterval uint64
+}
+
+func (c *Config) rekeyInterval() uint64 {
+	if c.RekeyInterval == 0 {
+		return DefaultRekeyInterval
+	}
+	return c.RekeyInterval
+}
+
+// halfConn é o estado de um sentido: chave, AEAD e número de sequência.
+type halfConn struct {
+	key  [keySize]byte
+	aead cipher.AEAD
+	seq  uint64
+	// epoch conta as trocas de chave; o número de sequência volta a zero
+	// em cada uma.
+	epoch uint64
+}
+
+func (h *halfConn) setKey(key [keySize]byte) error {
+	b, err := ginga.NewCipher(key[:])
+	if err != nil {
+		return err
+	}
+	a, err := ginga.NewOCB(b, nonceSize, tagSize)
+	if err != nil {
+		return err
+	}
+	h.key, h.aead, h.seq = key, a, 0
+	return nil
+}
+
+// rekey troca a chave por HKDF-Expand(chave atual, "rekey").
+func (h *halfConn) rekey() error {
+	next, err := hkdf.Expand(gingahash.New, h.key[:], prologue+" rekey", keySize)
+	if err != nil {
+		return err
+	}
+	var k [keySize]byte
+	copy(k[:], next)
+	clear(next)
+	h.epoch++
+	return h.setKey(k)
+}
+
+// nonce é 0^4 || seq em big-endian.
+func (h *halfConn) nonce() []byte {
+	n := make([]byte, nonceSize)
+	binary.BigEndian.PutUint64(n[4:], h.seq)
+	return n
+}
+
+// advance incrementa o número de sequência e troca a chave no intervalo;
+// como interval cabe em 64 bits, o número de sequência nunca dá a volta.
+func (h *halfConn) advance(interval uint64) error {
+	h.seq++
+	if h.seq >= interval {
+		return h.rekey()
+	}
+	return nil
+}
+
+// Conn é uma conexão cifrada; implementa net.Conn. O handshake acontece na
+// primeira leitura ou escrita, ou em Handshake.
+type Conn struct {
+	conn     net.Conn
+	config   *Config
+	isClient bool
+	peer     *ecdh.PublicKey
+
+	handshakeMu  sync.Mutex
+	handshakeErr error
+	handshaked   atomic.Bool
+
+	in, out        halfConn
+	inMu, outMu    sync.Mutex
+	inErr, outErr  error  // erros permanentes de cada sentido
+	plain          []byte // texto claro recebido e ainda não lido
+	header, record []byte
+}
+
+// Client devolve o lado cliente de um canal seguro sobre conn.
+func Client(conn net.Conn, config *Config) *Conn {
+	return &Conn{conn: conn, config: config, isClient: true}
+}
+
+// Server devolve o lado servidor de um canal seguro sobre conn.
+func Server(conn net.Conn, config *Config) *Conn {
+	return &Conn{conn: conn, config: config}
+}
+
+// Handshake executa o handshake, se ainda não foi feito. Um erro de
+// handshake é permanente.
+func (c *Conn) Handshake() error {
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+	if c.handshaked.Load() || c.handshakeErr != nil {
+		return c.handshakeErr
+	}
+	if c.config == nil || c.config.PrivateKey == nil || len(c.config.PeerKeys) == 0 {
+		c.handshakeErr = errors.New("gingaconn: config needs a private key and at least one peer key")
+		return c.handshakeErr
+	}
+	if c.isClient {
+		c.handshakeErr = c.clientHandshake()
+	} else {
+		c.handshakeErr = c.serverHandshake()
+	}
+	c.handshaked.Store(c.handshakeErr == nil)
+	return c.handshakeErr
+}
+
+func (c *Conn) setKeys(write, read [keySize]byte) error {
+	if err := c.out.setKey(write); err != nil {
+		return err
+	}
+	return c.in.setKey(read)
+}
+
+// PeerKey devolve a chave estática do par, depois do handshake.
+func (c *Conn) PeerKey() *ecdh.PublicKey {
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+	return c.peer
+}
+
+// Epochs devolve quantas trocas de chave já houve na leitura e na escrita.
+func (c *Conn) Epochs() (read, write uint64) {
+	c.inMu.Lock()
+	read = c.in.epoch
+	c.inMu.Unlock()
+	c.outMu.Lock()
+	write = c.out.epoch
+	c.outMu.Unlock()
+	return read, write
+}
+
+// Write cifra p em registros de até MaxRecordSize bytes. O tipo e o tamanho
+// do registro entram como dado associado.
+func (c *Conn) Write(p []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	if c.outErr != nil {
+		return 0, c.outErr
+	}
+
+	n := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), MaxRecordSize)]
+		if err := c.writeRecord(recordData, chunk); err != nil {
+			c.outErr = err
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+// writeRecord sela e envia um registro; chamado com outMu.
+func (c *Conn) writeRecord(typ byte, chunk []byte) error {
+	rec := make([]byte, headerSize, headerSize+len(chunk)+tagSize)
+	rec[0] = typ
+	binary.BigEndian.PutUint16(rec[1:], uint16(len(chunk)+tagSize))
+	rec = c.out.aead.Seal(rec, c.out.nonce(), chunk, rec[:headerSize])
+	if _, err := c.conn.Write(rec); err != nil {
+		return err
+	}
+	return c.out.advance(c.config.rekeyInterval())
+}
+
+// Read decifra o próximo registro quando não há texto claro pendente. Um
+// registro adulterado encerra a leitura com erro permanente. O fim do fluxo
+// só é io.EOF depois do registro de fechamento do par; sem ele, a conexão
+// foi cortada e Read devolve io.ErrUnexpectedEOF.
+func (c *Conn) Read(p []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	c.inMu.Lock()
+	defer c.inMu.Unlock()
+	for len(c.plain) == 0 {
+		if c.inErr != nil {
+			return 0, c.inErr
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+		if err := c.readRecord(); err != nil {
+			c.inErr = err
+		}
+	}
+	n := copy(p, c.plain)
+	c.plain = c.plain[n:]
+	return n, nil
+}
+
+func (c *Conn) readRecord() error {
+	if c.header == nil {
+		c.header = make([]byte, headerSize)
+	}
+	if _, err := io.ReadFull(c.conn, c.header); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	typ := c.header[0]
+	if typ != recordData && typ != recordClose {
+		return errRecordType
+	}
+	size := int(binary.BigEndian.Uint16(c.header[1:]))
+	if size < tagSize || size > MaxRecordSize+tagSize {
+		return errRecordSize
+	}
+	if cap(c.record) < size {
+		c.record = make([]byte, MaxRecordSize+tagSize)
+	}
+	rec := c.record[:size]
+	if _, err := io.ReadFull(c.conn, rec); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	plain, err := c.in.aead.Open(rec[:0], c.in.nonce(), rec, c.header)
+	if err != nil {
+		return errRecordAuth
+	}
+	if typ == recordClose {
+		if len(plain) != 0 {
+			return errRecordAuth
+		}
+		return io.EOF
+	}
+	c.plain = plain
+	return c.in.advance(c.config.rekeyInterval())
+}
+
+// Close envia o registro de fechamento, se o handshake já terminou, e fecha
+// a conexão subjacente. O envio espera no máximo closeNotifyTimeout; o prazo
+// é marcado antes de tomar outMu, para que um Write parado num par que não
+// lê também desista e não prenda Close.
+func (c *Conn) Close() error {
+	var notifyErr error
+	if c.handshaked.Load() {
+		c.conn.SetWriteDeadline(time.Now().Add(closeNotifyTimeout))
+		notifyErr = c.closeNotify()
+	}
+	if err := c.conn.Close(); err != nil {
+		return err
+	}
+	return notifyErr
+}
+
+func (c *Conn) closeNotify() error {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	if c.outErr != nil {
+		return nil
+	}
+	err := c.writeRecord(recordClose, nil)
+	c.outErr = net.ErrClosed
+	return err
+}
+
+func (c *Conn) LocalAddr() net.Addr                { return c.conn.LocalAddr() }
+func (c *Conn) RemoteAddr() net.Addr               { return c.conn.RemoteAddr() }
+func (c *Conn) SetDeadline(t time.Time) error      { return c.conn.SetDeadline(t) }
+func (c *Conn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *Conn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
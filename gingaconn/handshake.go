@@ -0,0 +1,199 @@
+package gingaconn
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// --- Handshake ---
+//
+// Três mensagens, com as chaves estáticas fixadas (pinned) nos dois lados:
+//
+//	cliente → servidor: versão || e_c || s_c
+//	servidor → cliente: e_s || s_s || HMAC(confirm_s, th)
+//	cliente → servidor: HMAC(confirm_c, th)
+//
+// O segredo é ee || es || se (DH efêmero–efêmero, efêmero–estático e
+// estático–efêmero), de modo que só quem tem a chave estática privada do
+// servidor calcula es e só quem tem a do cliente calcula se; as duas
+// confirmações provam isso. th é o GingaHash do prólogo e das chaves
+// públicas trocadas, e as chaves de cada sentido saem de HKDF-GingaHash.
+
+const (
+	version  = 1
+	prologue = "gingaconn v1"
+	keySize  = 32
+	macSize  = gingahash.DigestSize
+)
+
+var (
+	errPeerNotPinned = errors.New("gingaconn: peer static key is not pinned")
+	errBadConfirm    = errors.New("gingaconn: handshake confirmation failed")
+	errBadVersion    = errors.New("gingaconn: unsupported protocol version")
+)
+
+// sessionKeys são as chaves derivadas do handshake.
+type sessionKeys struct {
+	clientWrite, serverWrite [keySize]byte
+	confirmC, confirmS       [keySize]byte
+}
+
+func deriveKeys(secret, th []byte) (*sessionKeys, error) {
+	okm, err := hkdf.Key(gingahash.New, secret, th, prologue+" keys", 4*keySize)
+	if err != nil {
+		return nil, err
+	}
+	k := new(sessionKeys)
+	copy(k.clientWrite[:], okm[0:])
+	copy(k.serverWrite[:], okm[keySize:])
+	copy(k.confirmC[:], okm[2*keySize:])
+	copy(k.confirmS[:], okm[3*keySize:])
+	clear(okm)
+	return k, nil
+}
+
+func confirmMAC(key *[keySize]byte, th []byte) []byte {
+	m := hmac.New(gingahash.New, key[:])
+	m.Write(th)
+	return m.Sum(nil)
+}
+
+func transcript(msg1, eS, sS []byte) []byte {
+	h := gingahash.New()
+	h.Write([]byte(prologue))
+	h.Write(msg1)
+	h.Write(eS)
+	h.Write(sS)
+	return h.Sum(nil)
+}
+
+// pinned informa se pub está entre as chaves aceitas; a comparação não para
+// na primeira coincidência.
+func (c *Config) pinned(pub []byte) (*ecdh.PublicKey, bool) {
+	var found *ecdh.PublicKey
+	for _, k := range c.PeerKeys {
+		if subtle.ConstantTimeCompare(k.Bytes(), pub) == 1 {
+			found = k
+		}
+	}
+	return found, found != nil
+}
+
+type dhPair struct {
+	priv *ecdh.PrivateKey
+	pub  *ecdh.PublicKey
+}
+
+// dh concatena os segredos de cada par, na ordem ee, es, se; cada lado
+// monta os pares com as suas chaves privadas.
+func dh(pairs ...dhPair) ([]byte, error) {
+	var secret []byte
+	for _, p := range pairs {
+		z, err := p.priv.ECDH(p.pub)
+		if err != nil {
+			return nil, err
+		}
+		secret = append(secret, z...)
+	}
+	return secret, nil
+}
+
+func (c *Conn) clientHandshake() error {
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	msg1 := append([]byte{version}, e.PublicKey().Bytes()...)
+	msg1 = append(msg1, c.config.PrivateKey.PublicKey().Bytes()...)
+	if _, err := c.conn.Write(msg1); err != nil {
+		return err
+	}
+
+	msg2 := make([]byte, 2*keySize+macSize)
+	if _, err := io.ReadFull(c.conn, msg2); err != nil {
+		return err
+	}
+	eSBytes, sSBytes, macS := msg2[:keySize], msg2[keySize:2*keySize], msg2[2*keySize:]
+	sS, ok := c.config.pinned(sSBytes)
+	if !ok {
+		return errPeerNotPinned
+	}
+	eS, err := ecdh.X25519().NewPublicKey(eSBytes)
+	if err != nil {
+		return err
+	}
+	secret, err := dh(dhPair{e, eS}, dhPair{e, sS}, dhPair{c.config.PrivateKey, eS})
+	if err != nil {
+		return err
+	}
+	th := transcript(msg1, eSBytes, sSBytes)
+	keys, err := deriveKeys(secret, th)
+	clear(secret)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(macS, confirmMAC(&keys.confirmS, th)) {
+		return errBadConfirm
+	}
+	if _, err := c.conn.Write(confirmMAC(&keys.confirmC, th)); err != nil {
+		return err
+	}
+	c.peer = sS
+	return c.setKeys(keys.clientWrite, keys.serverWrite)
+}
+
+func (c *Conn) serverHandshake() error {
+	msg1 := make([]byte, 1+2*keySize)
+	if _, err := io.ReadFull(c.conn, msg1); err != nil {
+		return err
+	}
+	if msg1[0] != version {
+		return errBadVersion
+	}
+	sC, ok := c.config.pinned(msg1[1+keySize:])
+	if !ok {
+		return errPeerNotPinned
+	}
+	eC, err := ecdh.X25519().NewPublicKey(msg1[1 : 1+keySize])
+	if err != nil {
+		return err
+	}
+
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	s := c.config.PrivateKey
+	secret, err := dh(dhPair{e, eC}, dhPair{s, eC}, dhPair{e, sC})
+	if err != nil {
+		return err
+	}
+	eSBytes, sSBytes := e.PublicKey().Bytes(), s.PublicKey().Bytes()
+	th := transcript(msg1, eSBytes, sSBytes)
+	keys, err := deriveKeys(secret, th)
+	clear(secret)
+	if err != nil {
+		return err
+	}
+	msg2 := append(append(eSBytes, sSBytes...), confirmMAC(&keys.confirmS, th)...)
+	if _, err := c.conn.Write(msg2); err != nil {
+		return err
+	}
+
+	macC := make([]byte, macSize)
+	if _, err := io.ReadFull(c.conn, macC); err != nil {
+		return err
+	}
+	if !hmac.Equal(macC, confirmMAC(&keys.confirmC, th)) {
+		return errBadConfirm
+	}
+	c.peer = sC
+	return c.setKeys(keys.serverWrite, keys.clientWrite)
+}
@@ -0,0 +1,340 @@
+// Package gingadisk transforma um io.ReaderAt/io.WriterAt num dispositivo
+// de blocos cifrado, com acesso aleatório: cada bloco de tamanho fixo é
+// selado com Ginga-OCB3 em separado, de modo que ler ou escrever no meio de
+// um arquivo grande só toca os blocos envolvidos.
+//
+// Formato de cada bloco no armazenamento:
+//
+//	versão (8 bytes, big-endian) || texto cifrado || tag (16 bytes)
+//
+// O nonce é o índice do bloco (7 bytes) || versão (8 bytes); a versão
+// aumenta a cada reescrita do bloco, então o par (chave, nonce) nunca se
+// repete enquanto o armazenamento não for revertido por um atacante. O último
+// bloco pode ser menor que os demais e é selado com a marca de último bloco
+// no dado associado, o que denuncia um arquivo truncado num limite de bloco.
+//
+// Não são detectados: a troca de um bloco por uma versão anterior dele
+// mesmo (rollback) e a troca do arquivo inteiro por outro com a mesma chave;
+// use uma chave por arquivo.
+package gingadisk
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+const (
+	// DefaultBlockSize é o tamanho de bloco de texto claro sugerido.
+	DefaultBlockSize = 4096
+
+	// MinBlockSize e MaxBlockSize limitam o tamanho de bloco aceito.
+	MinBlockSize = 16
+	MaxBlockSize = 1 << 20
+
+	versionSize = 8
+	tagSize     = 16
+	nonceSize   = 15
+
+	// Overhead é o acréscimo, em bytes, de cada bloco no armazenamento.
+	Overhead = versionSize + tagSize
+
+	maxBlocks = 1 << 56 // o índice ocupa 7 bytes do nonce
+)
+
+// IntegrityError indica que um bloco não passou na autenticação: foi
+// adulterado, truncado ou cifrado com outra chave.
+type IntegrityError struct {
+	Block int64
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("gingadisk: block %d failed authentication", e.Block)
+}
+
+var (
+	errReadOnly  = errors.New("gingadisk: device is read-only")
+	errNegative  = errors.New("gingadisk: negative offset")
+	errTooLarge  = errors.New("gingadisk: offset beyond the maximum device size")
+	errVersion   = errors.New("gingadisk: block version counter exhausted")
+	errBadLength = errors.New("gingadisk: storage size is not a valid sequence of blocks")
+)
+
+// Storage é o armazenamento de um Device gravável: escritas parciais de um
+// bloco precisam ler o bloco antes de selá-lo de novo.
+type Storage interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// Device é um dispositivo de blocos cifrado; implementa io.ReaderAt e, se
+// criado com New, io.WriterAt. É seguro para uso concorrente.
+type Device struct {
+	r         io.ReaderAt
+	w         io.WriterAt // nil se somente leitura
+	aead      cipher.AEAD
+	blockSize int64
+	maxSize   int64 // limite do índice no nonce e do offset no armazenamento
+
+	mu   sync.RWMutex
+	size int64 // tamanho lógico, em bytes de texto claro
+}
+
+// New devolve um dispositivo gravável sobre s, cujo tamanho atual no
+// armazenamento é storageSize (zero para um dispositivo novo). key tem 32
+// bytes e blockSize é o tamanho de bloco de texto claro, o mesmo usado ao
+// criar o dispositivo.
+//
+// As versões dos blocos vêm do armazenamento, então storageSize precisa ser
+// o tamanho real: com um valor menor, blocos já escritos seriam tratados
+// como novos e selados de novo com um nonce já usado. New confere que s
+// termina em storageSize, e o dispositivo deve ser o único a escrever em s.
+func New(s Storage, storageSize int64, key []byte, blockSize int) (*Device, error) {
+	d, err := newDevice(s, storageSize, key, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	var probe [1]byte
+	if n, err := s.ReadAt(probe[:], storageSize); n != 0 || err != io.EOF {
+		return nil, errors.New("gingadisk: storage does not end at the given size")
+	}
+	d.w = s
+	return d, nil
+}
+
+// NewReader devolve um dispositivo somente leitura sobre r; os parâmetros
+// são os de New.
+func NewReader(r io.ReaderAt, storageSize int64, key []byte, blockSize int) (*Device, error) {
+	return newDevice(r, storageSize, key, blockSize)
+}
+
+func newDevice(r io.ReaderAt, storageSize int64, key []byte, blockSize int) (*Device, error) {
+	if blockSize < MinBlockSize || blockSize > MaxBlockSize {
+		return nil, errors.New("gingadisk: block size out of range")
+	}
+	b, err := ginga.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	a, err := ginga.NewOCB(b, nonceSize, tagSize)
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{r: r, aead: a, blockSize: int64(blockSize)}
+	d.maxSize = min(maxBlocks, math.MaxInt64/d.physBlockSize()) * d.blockSize
+
+	// O tamanho lógico sai do tamanho no armazenamento: blocos cheios e um
+	// último bloco com ao menos um byte de texto claro.
+	if storageSize < 0 {
+		return nil, errBadLength
+	}
+	phys := d.physBlockSize()
+	full, rest := storageSize/phys, storageSize%phys
+	switch {
+	case rest == 0:
+		d.size = full * d.blockSize
+	case rest > Overhead:
+		d.size = full*d.blockSize + rest - Overhead
+	default:
+		return nil, errBadLength
+	}
+	if d.size > d.maxSize {
+		return nil, errBadLength
+	}
+	return d, nil
+}
+
+func (d *Device) physBlockSize() int64 { return d.blockSize + Overhead }
+
+// Size devolve o tamanho lógico do dispositivo, em bytes.
+func (d *Device) Size() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.size
+}
+
+// StorageSize devolve o tamanho no armazenamento correspondente a um
+// tamanho lógico size.
+func (d *Device) StorageSize(size int64) int64 {
+	n := (size + d.blockSize - 1) / d.blockSize
+	return size + n*Overhead
+}
+
+// --- Blocos ---
+
+// blockLen é o tamanho do texto claro do bloco i num dispositivo de tamanho
+// size, e last informa se i é o último bloco.
+func (d *Device) blockLen(i, size int64) (n int64, last bool) {
+	n = min(d.blockSize, size-i*d.blockSize)
+	return n, (i+1)*d.blockSize >= size
+}
+
+func (d *Device) nonce(i int64, version uint64) []byte {
+	var n [nonceSize]byte
+	var idx [8]byte
+	binary.BigEndian.PutUint64(idx[:], uint64(i))
+	copy(n[:7], idx[1:])
+	binary.BigEndian.PutUint64(n[7:], version)
+	return n[:]
+}
+
+func lastFlag(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// readBlock lê e abre o bloco i, que tem n bytes de texto claro, e acrescenta
+// o texto claro a dst. Um bloco do meio ainda selado como último é aceito:
+// é o antigo último bloco de uma extensão interrompida (veja writeAt).
+func (d *Device) readBlock(dst []byte, i, n int64, last bool) ([]byte, uint64, error) {
+	raw := make([]byte, versionSize+n+tagSize)
+	// Um io.ReaderAt pode devolver io.EOF junto com o último byte.
+	if m, err := d.r.ReadAt(raw, i*d.physBlockSize()); err != nil && m < len(raw) {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	version := binary.BigEndian.Uint64(raw)
+	out, err := d.aead.Open(dst, d.nonce(i, version), raw[versionSize:], lastFlag(last))
+	if err != nil && !last {
+		out, err = d.aead.Open(dst, d.nonce(i, version), raw[versionSize:], lastFlag(true))
+	}
+	if err != nil {
+		return nil, 0, &IntegrityError{Block: i}
+	}
+	return out, version, nil
+}
+
+// writeBlock sela plain como o bloco i, com a versão seguinte a version.
+func (d *Device) writeBlock(i int64, plain []byte, version uint64, last bool) error {
+	if version == math.MaxUint64 {
+		return errVersion
+	}
+	version++
+	raw := make([]byte, versionSize, versionSize+len(plain)+tagSize)
+	binary.BigEndian.PutUint64(raw, version)
+	raw = d.aead.Seal(raw, d.nonce(i, version), plain, lastFlag(last))
+	_, err := d.w.WriteAt(raw, i*d.physBlockSize())
+	return err
+}
+
+// --- Leitura e escrita ---
+
+// ReadAt lê len(p) bytes a partir de off, decifrando só os blocos
+// envolvidos. Como io.ReaderAt, devolve io.EOF se chegar ao fim do
+// dispositivo antes de preencher p.
+func (d *Device) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errNegative
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if off >= d.size {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), d.size)
+	buf := make([]byte, 0, d.blockSize)
+	n := 0
+	for i := off / d.blockSize; i*d.blockSize < end; i++ {
+		bn, last := d.blockLen(i, d.size)
+		plain, _, err := d.readBlock(buf[:0], i, bn, last)
+		if err != nil {
+			return n, err
+		}
+		start := max(off-i*d.blockSize, 0)
+		stop := min(end-i*d.blockSize, bn)
+		n += copy(p[n:], plain[start:stop])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt escreve p a partir de off. Blocos parciais são lidos, conferidos
+// e selados de novo; escrever além do fim preenche o intervalo com zeros.
+func (d *Device) WriteAt(p []byte, off int64) (int, error) {
+	if d.w == nil {
+		return 0, errReadOnly
+	}
+	if off < 0 {
+		return 0, errNegative
+	}
+	if off > d.maxSize-int64(len(p)) {
+		return 0, errTooLarge
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	zeros := make([]byte, d.blockSize)
+	for d.size < off {
+		if _, err := d.writeAt(zeros[:min(d.blockSize, off-d.size)], d.size); err != nil {
+			return 0, err
+		}
+	}
+	return d.writeAt(p, off)
+}
+
+// writeAt escreve p em off ≤ d.size.
+func (d *Device) writeAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	end := off + int64(len(p))
+	newSize := max(d.size, end)
+	buf := make([]byte, 0, d.blockSize)
+	n := 0
+	for i := off / d.blockSize; i*d.blockSize < end; i++ {
+		plain, version := buf[:0], uint64(0)
+		if i*d.blockSize < d.size {
+			bn, last := d.blockLen(i, d.size)
+			var err error
+			if plain, version, err = d.readBlock(buf[:0], i, bn, last); err != nil {
+				return n, err
+			}
+		}
+		bn, last := d.blockLen(i, newSize)
+		plain = plain[:bn]
+		// Com o bloco estendido, os bytes entre o antigo fim e o trecho
+		// escrito ficam zerados.
+		if old := max(d.size-i*d.blockSize, 0); old < bn {
+			clear(plain[old:])
+		}
+		start := max(off-i*d.blockSize, 0)
+		c := copy(plain[start:], p[n:])
+		if err := d.writeBlock(i, plain, version, last); err != nil {
+			return n, err
+		}
+		n += c
+	}
+
+	// O antigo último bloco, se cheio e fora deste trecho, perde a marca de
+	// último bloco só depois que os novos blocos estão gravados. Se a escrita
+	// parar entre os dois passos, ele continua legível, pois readBlock aceita
+	// a marca num bloco do meio; só o truncamento de volta ao tamanho antigo
+	// deixa de ser detectado, como se a extensão não tivesse acontecido.
+	if oldLast := d.size/d.blockSize - 1; newSize > d.size && d.size%d.blockSize == 0 && oldLast >= 0 && oldLast < off/d.blockSize {
+		plain, version, err := d.readBlock(buf[:0], oldLast, d.blockSize, true)
+		if err != nil {
+			return n, err
+		}
+		if err := d.writeBlock(oldLast, plain, version, false); err != nil {
+			return n, err
+		}
+	}
+	d.size = newSize
+	return n, nil
+}
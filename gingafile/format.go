@@ -0,0 +1,167 @@
+package gingafile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// --- Cabeçalho ---
+//
+// O cabeçalho é texto, uma linha por campo:
+//
+//	ginga-file/v1
+//	-> X25519 <efêmera em base64>
+//	<chave do arquivo cifrada, em base64>
+//	-> scrypt <sal em base64> <fator de trabalho>
+//	<chave do arquivo cifrada, em base64>
+//	--- <HMAC-GingaHash do cabeçalho em base64>
+//
+// O corpo de cada stanza é quebrado em linhas de 64 colunas; a última linha
+// tem menos de 64 (pode ser vazia), o que marca o fim do corpo. O HMAC cobre
+// tudo desde a linha de versão até "---", inclusive.
+
+const (
+	intro         = "ginga-file/v1\n"
+	stanzaPrefix  = "->"
+	footerPrefix  = "---"
+	columnsPerRow = 64
+)
+
+var b64 = base64.RawStdEncoding.Strict()
+
+// Stanza é o bloco do cabeçalho que leva a chave do arquivo cifrada para um
+// destinatário: o tipo, os argumentos e o corpo.
+type Stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+func (s *Stanza) marshal(w io.Writer) error {
+	line := stanzaPrefix + " " + s.Type
+	for _, a := range s.Args {
+		line += " " + a
+	}
+	if _, err := io.WriteString(w, line+"\n"); err != nil {
+		return err
+	}
+	body := b64.EncodeToString(s.Body)
+	for {
+		n := min(len(body), columnsPerRow)
+		if _, err := io.WriteString(w, body[:n]+"\n"); err != nil {
+			return err
+		}
+		if n < columnsPerRow {
+			return nil
+		}
+		body = body[n:]
+	}
+}
+
+type header struct {
+	recipients []*Stanza
+	mac        []byte
+}
+
+// marshalWithoutMAC escreve o cabeçalho até "---", que é o trecho coberto
+// pelo HMAC.
+func (h *header) marshalWithoutMAC(w io.Writer) error {
+	if _, err := io.WriteString(w, intro); err != nil {
+		return err
+	}
+	for _, s := range h.recipients {
+		if err := s.marshal(w); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, footerPrefix)
+	return err
+}
+
+// validArg aceita só ASCII visível, para que o cabeçalho continue sendo
+// texto de uma linha por campo.
+func validArg(a string) bool {
+	if a == "" {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if a[i] < 33 || a[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+var errMalformedHeader = errors.New("gingafile: malformed header")
+
+// parseHeader lê o cabeçalho de r e devolve também o trecho coberto pelo
+// HMAC. Linhas maiores que o buffer de r são rejeitadas.
+func parseHeader(r *bufio.Reader) (*header, []byte, error) {
+	var raw bytes.Buffer
+	readLine := func() (string, error) {
+		line, err := r.ReadSlice('\n')
+		if err != nil {
+			if err == io.EOF || err == bufio.ErrBufferFull {
+				err = errMalformedHeader
+			}
+			return "", err
+		}
+		raw.Write(line)
+		return string(line[:len(line)-1]), nil
+	}
+
+	if line, err := readLine(); err != nil {
+		return nil, nil, err
+	} else if line+"\n" != intro {
+		return nil, nil, errors.New("gingafile: unsupported format or version")
+	}
+
+	h := new(header)
+	for {
+		line, err := readLine()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if rest, ok := strings.CutPrefix(line, footerPrefix+" "); ok {
+			mac, err := b64.DecodeString(rest)
+			if err != nil || len(mac) != macSize {
+				return nil, nil, errMalformedHeader
+			}
+			h.mac = mac
+			headerBytes := raw.Bytes()[:raw.Len()-len(line)-1+len(footerPrefix)]
+			return h, headerBytes, nil
+		}
+
+		rest, ok := strings.CutPrefix(line, stanzaPrefix+" ")
+		if !ok {
+			return nil, nil, errMalformedHeader
+		}
+		args := strings.Split(rest, " ")
+		for _, a := range args {
+			if !validArg(a) {
+				return nil, nil, errMalformedHeader
+			}
+		}
+		s := &Stanza{Type: args[0], Args: args[1:]}
+		for {
+			line, err := readLine()
+			if err != nil {
+				return nil, nil, err
+			}
+			b, err := b64.DecodeString(line)
+			if err != nil || len(line) > columnsPerRow {
+				return nil, nil, errMalformedHeader
+			}
+			s.Body = append(s.Body, b...)
+			if len(line) < columnsPerRow {
+				break
+			}
+		}
+		h.recipients = append(h.recipients, s)
+	}
+}
@@ -0,0 +1,226 @@
+// Package gingafile é um formato de arquivo cifrado para vários
+// destinatários, no estilo do age: uma chave de arquivo Ginga aleatória é
+// cifrada para cada destinatário (chaves X25519 ou senhas com scrypt), o
+// cabeçalho é autenticado com HMAC-GingaHash e o conteúdo segue em blocos de
+// 64 KiB cifrados com Ginga-OCB3.
+//
+// O cabeçalho mostra quantos destinatários há e, sem preenchimento, o
+// tamanho do arquivo revela exatamente o do texto claro. Um arquivo para
+// destinatários X25519 não identifica quem o cifrou: qualquer um com a chave
+// pública pode criá-lo.
+package gingafile
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pedroalbanese/ginga"
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+const (
+	fileKeySize = 32
+	macSize     = gingahash.DigestSize
+	nonceSize   = 16
+)
+
+// Recipient cifra a chave do arquivo para um destinatário, em uma ou mais
+// stanzas do cabeçalho.
+type Recipient interface {
+	Wrap(fileKey []byte) ([]*Stanza, error)
+}
+
+// Identity recupera a chave do arquivo a partir das stanzas do cabeçalho.
+// Quando nenhuma stanza é dela, devolve ErrIncorrectIdentity.
+type Identity interface {
+	Unwrap(stanzas []*Stanza) ([]byte, error)
+}
+
+// ErrIncorrectIdentity indica que a identidade não abre nenhuma das stanzas.
+var ErrIncorrectIdentity = errors.New("gingafile: incorrect identity for recipient block")
+
+// NoIdentityMatchError é devolvido por Decrypt quando nenhuma identidade abre
+// o arquivo.
+type NoIdentityMatchError struct {
+	Errors []error
+}
+
+func (e *NoIdentityMatchError) Error() string {
+	return "gingafile: no identity matched any of the recipients"
+}
+
+// --- Chaves derivadas ---
+
+func headerMAC(fileKey, headerBytes []byte) ([]byte, error) {
+	key, err := hkdf.Key(gingahash.New, fileKey, nil, "ginga-file/v1 header", macSize)
+	if err != nil {
+		return nil, err
+	}
+	m := hmac.New(gingahash.New, key)
+	m.Write(headerBytes)
+	return m.Sum(nil), nil
+}
+
+func payloadKey(fileKey, nonce []byte) ([]byte, error) {
+	return hkdf.Key(gingahash.New, fileKey, nonce, "ginga-file/v1 payload", fileKeySize)
+}
+
+// newAEAD devolve Ginga-OCB3 com nonce de 12 bytes e tag de 16.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	b, err := ginga.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ginga.NewOCB(b, 12, 16)
+}
+
+// wrapFileKey e unwrapFileKey cifram a chave do arquivo com uma chave de uso
+// único, por isso o nonce é zero.
+func wrapFileKey(key, fileKey []byte) ([]byte, error) {
+	a, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return a.Seal(nil, make([]byte, a.NonceSize()), fileKey, nil), nil
+}
+
+func unwrapFileKey(key, body []byte) ([]byte, error) {
+	a, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) != fileKeySize+a.Overhead() {
+		return nil, errors.New("gingafile: invalid stanza body size")
+	}
+	fileKey, err := a.Open(nil, make([]byte, a.NonceSize()), body, nil)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	return fileKey, nil
+}
+
+// --- Cifrar e decifrar ---
+
+// Encrypt escreve o cabeçalho em dst e devolve um io.WriteCloser que cifra o
+// que for escrito nele. Close grava o último bloco, sem fechar dst; sem
+// Close, o arquivo fica truncado e não decifra.
+func Encrypt(dst io.Writer, recipients ...Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("gingafile: no recipients specified")
+	}
+
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	h := new(header)
+	for i, r := range recipients {
+		stanzas, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, fmt.Errorf("gingafile: failed to wrap key for recipient #%d: %w", i, err)
+		}
+		h.recipients = append(h.recipients, stanzas...)
+	}
+	if err := checkScryptAlone(h.recipients); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := h.marshalWithoutMAC(&buf); err != nil {
+		return nil, err
+	}
+	mac, err := headerMAC(fileKey, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString(" " + b64.EncodeToString(mac) + "\n")
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	buf.Write(nonce)
+	if _, err := dst.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	key, err := payloadKey(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(key, dst)
+}
+
+// Decrypt lê o cabeçalho de src, abre a chave do arquivo com a primeira
+// identidade que servir, confere o HMAC do cabeçalho e devolve um io.Reader
+// com o conteúdo decifrado. Um bloco adulterado ou um arquivo truncado
+// aparecem como erro de Read.
+func Decrypt(src io.Reader, identities ...Identity) (io.Reader, error) {
+	if len(identities) == 0 {
+		return nil, errors.New("gingafile: no identities specified")
+	}
+
+	r := bufio.NewReader(src)
+	h, headerBytes, err := parseHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScryptAlone(h.recipients); err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	var errs []error
+	for _, id := range identities {
+		fileKey, err = id.Unwrap(h.recipients)
+		if errors.Is(err, ErrIncorrectIdentity) {
+			errs = append(errs, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	if fileKey == nil {
+		return nil, &NoIdentityMatchError{Errors: errs}
+	}
+
+	mac, err := headerMAC(fileKey, headerBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, h.mac) {
+		return nil, errors.New("gingafile: bad header MAC")
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, errors.New("gingafile: failed to read payload nonce")
+	}
+	key, err := payloadKey(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return newReader(key, r)
+}
+
+// checkScryptAlone exige que uma stanza scrypt seja a única do cabeçalho:
+// um arquivo com senha sugere que só quem a conhece o cifrou, o que deixa
+// de valer se houver também destinatários de chave pública.
+func checkScryptAlone(stanzas []*Stanza) error {
+	for _, s := range stanzas {
+		if s.Type == scryptType && len(stanzas) != 1 {
+			return errors.New("gingafile: a scrypt recipient must be the only one")
+		}
+	}
+	return nil
+}
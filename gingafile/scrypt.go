@@ -0,0 +1,129 @@
+package gingafile
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// --- Destinatários com senha (scrypt) ---
+//
+// A chave que cifra a chave do arquivo é scrypt(senha, rótulo || sal, N =
+// 2^logN, r = 8, p = 1). A stanza leva o sal e logN:
+//
+//	-> scrypt <sal em base64> <logN>
+//	<chave do arquivo cifrada>
+
+const (
+	scryptType     = "scrypt"
+	scryptLabel    = "ginga-file/v1/scrypt"
+	scryptSaltSize = 16
+
+	// DefaultWorkFactor é o logN usado por ScryptRecipient; cerca de um
+	// segundo num computador atual.
+	DefaultWorkFactor = 18
+
+	// DefaultMaxWorkFactor é o maior logN aceito por ScryptIdentity, para que
+	// um arquivo malicioso não exija memória e tempo sem limite.
+	DefaultMaxWorkFactor = 22
+)
+
+// ScryptRecipient cifra a chave do arquivo com uma senha. Precisa ser o
+// único destinatário do arquivo.
+type ScryptRecipient struct {
+	password   []byte
+	workFactor int
+}
+
+// NewScryptRecipient devolve um destinatário para a senha password.
+func NewScryptRecipient(password string) (*ScryptRecipient, error) {
+	if password == "" {
+		return nil, errors.New("gingafile: empty passphrase")
+	}
+	return &ScryptRecipient{password: []byte(password), workFactor: DefaultWorkFactor}, nil
+}
+
+// SetWorkFactor define logN, entre 1 e 30.
+func (r *ScryptRecipient) SetWorkFactor(logN int) {
+	if logN < 1 || logN > 30 {
+		panic("gingafile: scrypt work factor must be between 1 and 30")
+	}
+	r.workFactor = logN
+}
+
+// Wrap cifra a chave do arquivo com a senha.
+func (r *ScryptRecipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	salt := make([]byte, scryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := scryptKey(r.password, salt, r.workFactor)
+	if err != nil {
+		return nil, err
+	}
+	body, err := wrapFileKey(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{b64.EncodeToString(salt), strconv.Itoa(r.workFactor)}
+	return []*Stanza{{Type: scryptType, Args: args, Body: body}}, nil
+}
+
+func scryptKey(password, salt []byte, logN int) ([]byte, error) {
+	s := append([]byte(scryptLabel), salt...)
+	return scrypt.Key(password, s, 1<<logN, 8, 1, fileKeySize)
+}
+
+// ScryptIdentity abre arquivos cifrados com uma senha.
+type ScryptIdentity struct {
+	password      []byte
+	maxWorkFactor int
+}
+
+// NewScryptIdentity devolve uma identidade para a senha password.
+func NewScryptIdentity(password string) (*ScryptIdentity, error) {
+	if password == "" {
+		return nil, errors.New("gingafile: empty passphrase")
+	}
+	return &ScryptIdentity{password: []byte(password), maxWorkFactor: DefaultMaxWorkFactor}, nil
+}
+
+// SetMaxWorkFactor define o maior logN aceito, entre 1 e 30.
+func (i *ScryptIdentity) SetMaxWorkFactor(logN int) {
+	if logN < 1 || logN > 30 {
+		panic("gingafile: scrypt work factor must be between 1 and 30")
+	}
+	i.maxWorkFactor = logN
+}
+
+// Unwrap abre a stanza scrypt; uma senha errada dá ErrIncorrectIdentity.
+func (i *ScryptIdentity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != scryptType {
+			continue
+		}
+		if len(s.Args) != 2 {
+			return nil, errors.New("gingafile: invalid scrypt recipient block")
+		}
+		salt, err := b64.DecodeString(s.Args[0])
+		if err != nil || len(salt) != scryptSaltSize {
+			return nil, errors.New("gingafile: invalid scrypt recipient block")
+		}
+		logN, err := strconv.Atoi(s.Args[1])
+		if err != nil || logN < 1 || strconv.Itoa(logN) != s.Args[1] {
+			return nil, errors.New("gingafile: invalid scrypt work factor")
+		}
+		if logN > i.maxWorkFactor {
+			return nil, fmt.Errorf("gingafile: scrypt work factor too large: %d > %d", logN, i.maxWorkFactor)
+		}
+		key, err := scryptKey(i.password, salt, logN)
+		if err != nil {
+			return nil, err
+		}
+		return unwrapFileKey(key, s.Body)
+	}
+	return nil, ErrIncorrectIdentity
+}
@@ -0,0 +1,195 @@
+package gingafile
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// --- Conteúdo em blocos ---
+//
+// O conteúdo é dividido em blocos de ChunkSize bytes, cada um cifrado com
+// Ginga-OCB3 e nonce contador (11 bytes, big-endian) || marca de último
+// bloco. A marca impede truncar o arquivo num limite de bloco; só o primeiro
+// bloco pode ser vazio, e só quando o conteúdo inteiro é vazio.
+
+// ChunkSize é o tamanho do texto claro em cada bloco, exceto o último.
+const ChunkSize = 64 << 10
+
+const (
+	tagSize       = 16
+	encChunkSize  = ChunkSize + tagSize
+	lastChunkFlag = 0x01
+)
+
+var (
+	errChunkAuth  = errors.New("gingafile: failed to decrypt and authenticate payload chunk")
+	errTruncated  = errors.New("gingafile: payload truncated")
+	errEmptyChunk = errors.New("gingafile: last chunk is empty")
+	errCounter    = errors.New("gingafile: chunk counter wrapped around")
+	errClosed     = errors.New("gingafile: write to closed writer")
+)
+
+type chunkNonce [12]byte
+
+func (n *chunkNonce) set(counter uint64, last bool) {
+	*n = chunkNonce{}
+	binary.BigEndian.PutUint64(n[3:11], counter)
+	if last {
+		n[11] = lastChunkFlag
+	}
+}
+
+type writer struct {
+	a       cipher.AEAD
+	dst     io.Writer
+	buf     []byte // texto claro do bloco atual
+	out     []byte
+	nonce   chunkNonce
+	counter uint64
+	err     error
+}
+
+func newWriter(key []byte, dst io.Writer) (*writer, error) {
+	a, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{
+		a:   a,
+		dst: dst,
+		buf: make([]byte, 0, ChunkSize),
+		out: make([]byte, 0, encChunkSize),
+	}, nil
+}
+
+// Write guarda p em blocos; um bloco cheio só é gravado quando chega mais
+// texto, já que só então se sabe que não é o último.
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(w.buf) == ChunkSize {
+			if err := w.flush(false); err != nil {
+				w.err = err
+				return n, err
+			}
+		}
+		c := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close grava o último bloco. Não fecha o io.Writer de destino.
+func (w *writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	err := w.flush(true)
+	w.err = errClosed
+	if err != nil {
+		w.err = err
+	}
+	return err
+}
+
+func (w *writer) flush(last bool) error {
+	if w.counter == math.MaxUint64 {
+		return errCounter
+	}
+	w.nonce.set(w.counter, last)
+	w.out = w.a.Seal(w.out[:0], w.nonce[:], w.buf, nil)
+	if _, err := w.dst.Write(w.out); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	w.counter++
+	return nil
+}
+
+type reader struct {
+	a       cipher.AEAD
+	src     *bufio.Reader
+	buf     []byte
+	plain   []byte // texto claro decifrado e ainda não lido
+	nonce   chunkNonce
+	counter uint64
+	done    bool
+	err     error
+}
+
+func newReader(key []byte, src *bufio.Reader) (*reader, error) {
+	a, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{a: a, src: src, buf: make([]byte, encChunkSize)}, nil
+}
+
+// Read devolve o texto claro de cada bloco só depois de autenticá-lo. Erros
+// de autenticação e truncamento são permanentes.
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+		r.err = r.readChunk()
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// readChunk lê e decifra o próximo bloco. O último é o que termina antes de
+// encChunkSize bytes ou o cheio seguido do fim da entrada.
+func (r *reader) readChunk() error {
+	n, err := io.ReadFull(r.src, r.buf)
+	last := false
+	switch {
+	case err == io.EOF:
+		return errTruncated
+	case err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.src.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if n < tagSize {
+		return errTruncated
+	}
+	if r.counter == math.MaxUint64 {
+		return errCounter
+	}
+
+	r.nonce.set(r.counter, last)
+	plain, err := r.a.Open(r.buf[:0], r.nonce[:], r.buf[:n], nil)
+	if err != nil {
+		return errChunkAuth
+	}
+	if last && len(plain) == 0 && r.counter > 0 {
+		return errEmptyChunk
+	}
+	r.counter++
+	r.plain = plain
+	r.done = last
+	return nil
+}
@@ -0,0 +1,170 @@
+package gingafile
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// --- Destinatários X25519 ---
+//
+// Para cada destinatário R, uma chave efêmera e: a chave que cifra a chave do
+// arquivo é HKDF-GingaHash(X25519(e, R), sal = E || R). A stanza leva E:
+//
+//	-> X25519 <E em base64>
+//	<chave do arquivo cifrada>
+
+const (
+	x25519Type  = "X25519"
+	x25519Label = "ginga-file/v1/X25519"
+
+	// As chaves são escritas em base32 sem preenchimento depois de um
+	// prefixo, em minúsculas a pública e em maiúsculas a secreta.
+	recipientPrefix = "gingapub1"
+	identityPrefix  = "GINGA-SECRET-KEY-1"
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// X25519Recipient é a chave pública de um destinatário.
+type X25519Recipient struct {
+	pub *ecdh.PublicKey
+}
+
+// NewX25519Recipient devolve o destinatário da chave pública X25519 pub.
+func NewX25519Recipient(pub *ecdh.PublicKey) (*X25519Recipient, error) {
+	if pub.Curve() != ecdh.X25519() {
+		return nil, errors.New("gingafile: recipient key is not X25519")
+	}
+	return &X25519Recipient{pub: pub}, nil
+}
+
+// ParseX25519Recipient lê um destinatário no formato "gingapub1...".
+func ParseX25519Recipient(s string) (*X25519Recipient, error) {
+	rest, ok := strings.CutPrefix(s, recipientPrefix)
+	if !ok || strings.ToLower(rest) != rest {
+		return nil, errors.New("gingafile: malformed recipient")
+	}
+	b, err := b32.DecodeString(strings.ToUpper(rest))
+	if err != nil {
+		return nil, errors.New("gingafile: malformed recipient")
+	}
+	pub, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, errors.New("gingafile: malformed recipient")
+	}
+	return &X25519Recipient{pub: pub}, nil
+}
+
+// String devolve o destinatário no formato aceito por ParseX25519Recipient.
+func (r *X25519Recipient) String() string {
+	return recipientPrefix + strings.ToLower(b32.EncodeToString(r.pub.Bytes()))
+}
+
+// Wrap cifra a chave do arquivo para r.
+func (r *X25519Recipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := e.ECDH(r.pub)
+	if err != nil {
+		return nil, err
+	}
+	ourKey := e.PublicKey().Bytes()
+	key, err := x25519WrapKey(shared, ourKey, r.pub.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	body, err := wrapFileKey(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return []*Stanza{{Type: x25519Type, Args: []string{b64.EncodeToString(ourKey)}, Body: body}}, nil
+}
+
+func x25519WrapKey(shared, ephemeral, recipient []byte) ([]byte, error) {
+	salt := append(append([]byte(nil), ephemeral...), recipient...)
+	return hkdf.Key(gingahash.New, shared, salt, x25519Label, fileKeySize)
+}
+
+// X25519Identity é a chave privada de um destinatário.
+type X25519Identity struct {
+	priv *ecdh.PrivateKey
+}
+
+// GenerateX25519Identity gera uma identidade nova.
+func GenerateX25519Identity() (*X25519Identity, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &X25519Identity{priv: priv}, nil
+}
+
+// ParseX25519Identity lê uma identidade no formato "GINGA-SECRET-KEY-1...".
+func ParseX25519Identity(s string) (*X25519Identity, error) {
+	rest, ok := strings.CutPrefix(s, identityPrefix)
+	if !ok {
+		return nil, errors.New("gingafile: malformed secret key")
+	}
+	b, err := b32.DecodeString(rest)
+	if err != nil {
+		return nil, errors.New("gingafile: malformed secret key")
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return nil, errors.New("gingafile: malformed secret key")
+	}
+	return &X25519Identity{priv: priv}, nil
+}
+
+// Recipient devolve o destinatário que corresponde à identidade.
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{pub: i.priv.PublicKey()}
+}
+
+// String devolve a identidade no formato aceito por ParseX25519Identity.
+func (i *X25519Identity) String() string {
+	return identityPrefix + b32.EncodeToString(i.priv.Bytes())
+}
+
+// Unwrap procura entre as stanzas X25519 a que foi cifrada para i.
+func (i *X25519Identity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	ourKey := i.priv.PublicKey().Bytes()
+	for _, s := range stanzas {
+		if s.Type != x25519Type {
+			continue
+		}
+		if len(s.Args) != 1 {
+			return nil, errors.New("gingafile: invalid X25519 recipient block")
+		}
+		share, err := b64.DecodeString(s.Args[0])
+		if err != nil {
+			return nil, errors.New("gingafile: invalid X25519 recipient block")
+		}
+		pub, err := ecdh.X25519().NewPublicKey(share)
+		if err != nil {
+			return nil, errors.New("gingafile: invalid X25519 recipient block")
+		}
+		shared, err := i.priv.ECDH(pub)
+		if err != nil {
+			return nil, errors.New("gingafile: invalid X25519 recipient block")
+		}
+		key, err := x25519WrapKey(shared, share, ourKey)
+		if err != nil {
+			return nil, err
+		}
+		fileKey, err := unwrapFileKey(key, s.Body)
+		if errors.Is(err, ErrIncorrectIdentity) {
+			continue
+		}
+		return fileKey, err
+	}
+	return nil, ErrIncorrectIdentity
+}
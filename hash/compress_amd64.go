@@ -0,0 +1,10 @@
+//go:build amd64 && !purego
+
+package ginga
+
+//go:noescape
+func compressSSE2(state *[16]uint32, sk *[internalRounds][8]uint32)
+
+func compress(state *[16]uint32, sk *[internalRounds][8]uint32) {
+	compressSSE2(state, sk)
+}
@@ -0,0 +1,296 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// As 16 palavras do estado ficam em X0..X3 durante a camada de rodadas (as
+// rotações de round32 dependem só de r); mixState512 é sequencial, com uma
+// rotação diferente por palavra, e roda em registradores gerais sobre o
+// estado em memória.
+
+#define ROTL(n, x, t) \
+	MOVO  x, t;          \
+	PSLLL $((n)), x;     \
+	PSRLL $(32-(n)), t;  \
+	POR   t, x
+
+#define ROUND(x, k, n1, n2) \
+	PADDL k, x;       \
+	PXOR  X7, x;      \
+	PADDL X8, x;      \
+	ROTL(n1, x, X5);  \
+	PXOR  k, x;       \
+	ROTL(n2, x, X5)
+
+// MIX aplica state[i] ^= ROTL(state[j], n).
+#define MIX(i, j, n) \
+	MOVL (4*(j))(DI), AX; \
+	ROLL $((n)), AX;      \
+	XORL AX, (4*(i))(DI)
+
+// func compressSSE2(state *[16]uint32, sk *[internalRounds][8]uint32)
+TEXT ·compressSSE2(SB), NOSPLIT, $0-16
+	MOVQ state+0(FP), DI
+	MOVQ sk+8(FP), SI
+	MOVL   $0xA5A5A5A5, AX
+	MOVL   AX, X7
+	PSHUFD $0, X7, X7
+	MOVL   $0x3C3C3C3C, AX
+	MOVL   AX, X8
+	PSHUFD $0, X8, X8
+
+	// rodada 0
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 0(SI), X9
+	MOVOU 16(SI), X10
+	ROUND(X0, X9, 10, 5)
+	ROUND(X1, X10, 10, 5)
+	ROUND(X2, X9, 10, 5)
+	ROUND(X3, X10, 10, 5)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 1
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 32(SI), X9
+	MOVOU 48(SI), X10
+	ROUND(X0, X9, 11, 6)
+	ROUND(X1, X10, 11, 6)
+	ROUND(X2, X9, 11, 6)
+	ROUND(X3, X10, 11, 6)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 2
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 64(SI), X9
+	MOVOU 80(SI), X10
+	ROUND(X0, X9, 12, 7)
+	ROUND(X1, X10, 12, 7)
+	ROUND(X2, X9, 12, 7)
+	ROUND(X3, X10, 12, 7)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 3
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 96(SI), X9
+	MOVOU 112(SI), X10
+	ROUND(X0, X9, 13, 8)
+	ROUND(X1, X10, 13, 8)
+	ROUND(X2, X9, 13, 8)
+	ROUND(X3, X10, 13, 8)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 4
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 128(SI), X9
+	MOVOU 144(SI), X10
+	ROUND(X0, X9, 14, 9)
+	ROUND(X1, X10, 14, 9)
+	ROUND(X2, X9, 14, 9)
+	ROUND(X3, X10, 14, 9)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 5
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 160(SI), X9
+	MOVOU 176(SI), X10
+	ROUND(X0, X9, 15, 10)
+	ROUND(X1, X10, 15, 10)
+	ROUND(X2, X9, 15, 10)
+	ROUND(X3, X10, 15, 10)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 6
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 192(SI), X9
+	MOVOU 208(SI), X10
+	ROUND(X0, X9, 16, 11)
+	ROUND(X1, X10, 16, 11)
+	ROUND(X2, X9, 16, 11)
+	ROUND(X3, X10, 16, 11)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 7
+	MOVOU 0(DI), X0
+	MOVOU 16(DI), X1
+	MOVOU 32(DI), X2
+	MOVOU 48(DI), X3
+	MOVOU 224(SI), X9
+	MOVOU 240(SI), X10
+	ROUND(X0, X9, 17, 12)
+	ROUND(X1, X10, 17, 12)
+	ROUND(X2, X9, 17, 12)
+	ROUND(X3, X10, 17, 12)
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+	RET
@@ -0,0 +1,10 @@
+//go:build arm64 && ginga_neon && !purego
+
+package ginga
+
+//go:noescape
+func compressNEON(state *[16]uint32, sk *[internalRounds][8]uint32)
+
+func compress(state *[16]uint32, sk *[internalRounds][8]uint32) {
+	compressNEON(state, sk)
+}
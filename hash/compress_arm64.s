@@ -0,0 +1,247 @@
+//go:build arm64 && ginga_neon && !purego
+
+#include "textflag.h"
+
+// As 16 palavras do estado ficam em V0..V3 durante a camada de rodadas (as
+// rotações de round32 dependem só de r); mixState512 é sequencial, com uma
+// rotação diferente por palavra, e roda em registradores gerais sobre o
+// estado em memória.
+
+#define ROTL(n, x, t) \
+	VSHL  $((n)), x.S4, t.S4;     \
+	VUSHR $(32-(n)), x.S4, x.S4;  \
+	VORR  t.B16, x.B16, x.B16
+
+#define ROUND(x, k, n1, n2) \
+	VADD k.S4, x.S4, x.S4;     \
+	VEOR V7.B16, x.B16, x.B16; \
+	VADD V8.S4, x.S4, x.S4;    \
+	ROTL(n1, x, V5);           \
+	VEOR k.B16, x.B16, x.B16;  \
+	ROTL(n2, x, V5)
+
+// MIX aplica state[i] ^= ROTL(state[j], n).
+#define MIX(i, j, n) \
+	MOVWU (4*(j))(R0), R4;  \
+	RORW  $(32-(n)), R4, R4; \
+	MOVWU (4*(i))(R0), R6;  \
+	EORW  R4, R6, R6;       \
+	MOVW  R6, (4*(i))(R0)
+
+// func compressNEON(state *[16]uint32, sk *[internalRounds][8]uint32)
+TEXT ·compressNEON(SB), NOSPLIT, $0-16
+	MOVD state+0(FP), R0
+	MOVD sk+8(FP), R3
+	MOVW $0xA5A5A5A5, R4
+	VDUP R4, V7.S4
+	MOVW $0x3C3C3C3C, R4
+	VDUP R4, V8.S4
+
+	// rodada 0
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $0, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 10, 5)
+	ROUND(V1, V10, 10, 5)
+	ROUND(V2, V9, 10, 5)
+	ROUND(V3, V10, 10, 5)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 1
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $32, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 11, 6)
+	ROUND(V1, V10, 11, 6)
+	ROUND(V2, V9, 11, 6)
+	ROUND(V3, V10, 11, 6)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 2
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $64, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 12, 7)
+	ROUND(V1, V10, 12, 7)
+	ROUND(V2, V9, 12, 7)
+	ROUND(V3, V10, 12, 7)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 3
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $96, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 13, 8)
+	ROUND(V1, V10, 13, 8)
+	ROUND(V2, V9, 13, 8)
+	ROUND(V3, V10, 13, 8)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 4
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $128, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 14, 9)
+	ROUND(V1, V10, 14, 9)
+	ROUND(V2, V9, 14, 9)
+	ROUND(V3, V10, 14, 9)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 5
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $160, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 15, 10)
+	ROUND(V1, V10, 15, 10)
+	ROUND(V2, V9, 15, 10)
+	ROUND(V3, V10, 15, 10)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 6
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $192, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 16, 11)
+	ROUND(V1, V10, 16, 11)
+	ROUND(V2, V9, 16, 11)
+	ROUND(V3, V10, 16, 11)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+
+	// rodada 7
+	VLD1  (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ADD   $224, R3, R2
+	VLD1  (R2), [V9.S4, V10.S4]
+	ROUND(V0, V9, 17, 12)
+	ROUND(V1, V10, 17, 12)
+	ROUND(V2, V9, 17, 12)
+	ROUND(V3, V10, 17, 12)
+	VST1  [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	MIX(0, 3, 13)
+	MIX(1, 4, 20)
+	MIX(2, 5, 27)
+	MIX(3, 6, 2)
+	MIX(4, 7, 9)
+	MIX(5, 8, 16)
+	MIX(6, 9, 23)
+	MIX(7, 10, 30)
+	MIX(8, 11, 5)
+	MIX(9, 12, 12)
+	MIX(10, 13, 19)
+	MIX(11, 14, 26)
+	MIX(12, 15, 1)
+	MIX(13, 0, 8)
+	MIX(14, 1, 15)
+	MIX(15, 2, 22)
+	RET
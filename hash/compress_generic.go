@@ -0,0 +1,7 @@
+//go:build (!amd64 && !(arm64 && ginga_neon)) || purego
+
+package ginga
+
+func compress(state *[16]uint32, sk *[internalRounds][8]uint32) {
+	compressGeneric(state, sk)
+}
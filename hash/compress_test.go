@@ -0,0 +1,68 @@
+package ginga
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+)
+
+func randomWords(t *testing.T, w []uint32) {
+	b := make([]byte, 4*len(w))
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := range w {
+		w[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+}
+
+// TestCompressMatchGeneric confere a compressão da arquitetura (SSE2 em
+// amd64; NEON em arm64 com a tag ginga_neon) contra compressGeneric com
+// estados e subchaves aleatórios.
+func TestCompressMatchGeneric(t *testing.T) {
+	for n := 0; n < 1000; n++ {
+		var state [16]uint32
+		var sk [internalRounds][8]uint32
+		randomWords(t, state[:])
+		for r := range sk {
+			randomWords(t, sk[r][:])
+		}
+		want := state
+		compressGeneric(&want, &sk)
+		compress(&state, &sk)
+		if state != want {
+			t.Fatalf("compress difere de compressGeneric:\n%08x\n%08x", state, want)
+		}
+	}
+}
+
+// TestProcessBlockMatchGeneric refaz processBlock com compressGeneric e
+// confere o estado depois de cada bloco de uma mensagem aleatória.
+func TestProcessBlockMatchGeneric(t *testing.T) {
+	h := New().(*gingaHash)
+	want := h.state
+	block := make([]byte, BlockSize)
+	for n := 0; n < 1000; n++ {
+		rand.Read(block)
+		h.processBlock(block)
+
+		var m [8]uint32
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint32(block[4*i:])
+		}
+		var sk [internalRounds][8]uint32
+		for r := range sk {
+			for i := range sk[r] {
+				sk[r][i] = subKey32(&m, r, i)
+			}
+		}
+		prev := want
+		compressGeneric(&want, &sk)
+		for i := range want {
+			want[i] ^= m[i&7] ^ prev[i]
+		}
+		if h.state != want {
+			t.Fatalf("processBlock difere da referência no bloco %d", n)
+		}
+	}
+}
@@ -90,15 +90,17 @@ func (h *gingaHash) processBlock(block []byte) {
 
 	prev := h.state // salva o estado anterior
 
-	// compressão com mais estado
+	// subchaves da mensagem: as palavras i e i+8 usam a mesma
+	var sk [internalRounds][8]uint32
 	for r := 0; r < internalRounds; r++ {
-		for i := 0; i < 16; i++ {
-			k := subKey32(&m, r, i&7) // usa 8 palavras de mensagem
-			h.state[i] = round32(h.state[i], k, r)
+		for i := 0; i < 8; i++ {
+			sk[r][i] = subKey32(&m, r, i) // usa 8 palavras de mensagem
 		}
-		mixState512(&h.state)
 	}
 
+	// compressão com mais estado
+	compress(&h.state, &sk)
+
 	// Miyaguchi-Preneel: H = f(H, M) ⊕ M ⊕ H_prev
 	for i := 0; i < 16; i++ {
 		h.state[i] ^= m[i&7] ^ prev[i]
@@ -132,6 +134,17 @@ func subKey32(k *[8]uint32, round, i int) uint32 {
 	return rotl32(base^uint32(i*73+round*91), (round+i)&31)
 }
 
+// compressGeneric é a compressão em Go puro; as versões em assembly devem
+// produzir o mesmo estado.
+func compressGeneric(state *[16]uint32, sk *[internalRounds][8]uint32) {
+	for r := 0; r < internalRounds; r++ {
+		for i := 0; i < 16; i++ {
+			state[i] = round32(state[i], sk[r][i&7], r)
+		}
+		mixState512(state)
+	}
+}
+
 func mixState512(state *[16]uint32) {
 	for i := 0; i < 16; i++ {
 		state[i] ^= rotl32(state[(i+3)&15], (7*i+13)&31)
@@ -0,0 +1,182 @@
+package ginga
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// --- HCTR2 (cifra de bloco larga) ---
+
+// HCTR2 cifra mensagens de 16 bytes ou mais preservando o comprimento, como
+// um único bloco largo (Crowley, Huckleberry e Biggers, 2021): trocar um bit
+// em qualquer posição embaralha todo o texto cifrado. Combina a cifra de
+// bloco, o hash polinomial POLYVAL e o contador XCTR; aceita um ajuste
+// (tweak) de qualquer tamanho. Serve para nomes de arquivo e células de
+// banco de dados, onde não há espaço para nonce nem tag.
+type HCTR2 struct {
+	b    cipher.Block
+	hkey polyvalKey
+	l    [BlockSize]byte
+}
+
+// NewHCTR2 cria um HCTR2 sobre um bloco de 16 bytes; com NewCipher, é a
+// variante Ginga.
+func NewHCTR2(b cipher.Block) (*HCTR2, error) {
+	if b.BlockSize() != BlockSize {
+		return nil, errors.New("ginga: HCTR2 requires a 16-byte block cipher")
+	}
+	x := &HCTR2{b: b}
+	var h [BlockSize]byte
+	b.Encrypt(h[:], h[:])
+	x.hkey = newPolyvalKey(&h)
+	x.l[0] = 1
+	b.Encrypt(x.l[:], x.l[:])
+	return x, nil
+}
+
+// Encrypt cifra src (16 bytes ou mais) sob o ajuste tweak; dst pode ser src.
+func (x *HCTR2) Encrypt(dst, src, tweak []byte) {
+	x.crypt(dst, src, tweak, false)
+}
+
+// Decrypt desfaz Encrypt com o mesmo ajuste.
+func (x *HCTR2) Decrypt(dst, src, tweak []byte) {
+	x.crypt(dst, src, tweak, true)
+}
+
+func (x *HCTR2) crypt(dst, src, tweak []byte, decrypt bool) {
+	if len(src) < BlockSize {
+		panic("ginga: HCTR2 input must be at least 16 bytes")
+	}
+	if len(dst) < len(src) {
+		panic("ginga: output smaller than input")
+	}
+	dst = dst[:len(src)]
+	tw := x.hashTweak(tweak, len(src)%BlockSize == 0)
+
+	// M é o primeiro bloco e N o resto; na volta, U e V.
+	var mm, uu [BlockSize]byte
+	h := x.hashMessage(tw, src[BlockSize:])
+	xorBlock(&mm, src, h[:])
+	if decrypt {
+		x.b.Decrypt(uu[:], mm[:])
+	} else {
+		x.b.Encrypt(uu[:], mm[:])
+	}
+	var s [BlockSize]byte
+	xorBlock(&s, mm[:], uu[:])
+	xorBlock(&s, s[:], x.l[:])
+	x.xctr(dst[BlockSize:], src[BlockSize:], &s)
+	h = x.hashMessage(tw, dst[BlockSize:])
+	xorBlock((*[BlockSize]byte)(dst), uu[:], h[:])
+}
+
+// xctr é o contador XCTR: o bloco i (a partir de 1) é E(S ⊕ bin(i)), com i
+// em little-endian.
+func (x *HCTR2) xctr(dst, src []byte, s *[BlockSize]byte) {
+	var ks [batch * BlockSize]byte
+	lo, hi := binary.LittleEndian.Uint64(s[:8]), binary.LittleEndian.Uint64(s[8:])
+	for i := uint64(1); len(src) > 0; {
+		n := min(len(src), len(ks))
+		blocks := (n + BlockSize - 1) / BlockSize
+		for j := 0; j < blocks; j, i = j+1, i+1 {
+			binary.LittleEndian.PutUint64(ks[j*BlockSize:], lo^i)
+			binary.LittleEndian.PutUint64(ks[j*BlockSize+8:], hi)
+		}
+		encryptBlocks(x.b, ks[:blocks*BlockSize], ks[:blocks*BlockSize])
+		subtle.XORBytes(dst[:n], src[:n], ks[:n])
+		src, dst = src[n:], dst[n:]
+	}
+}
+
+// hashTweak absorve o bloco de tamanho e o ajuste, que são comuns aos dois
+// hashes de uma mesma chamada.
+func (x *HCTR2) hashTweak(tweak []byte, aligned bool) polyval {
+	var blk [BlockSize]byte
+	t := 2*8*uint64(len(tweak)) + 2
+	if !aligned {
+		t++
+	}
+	binary.LittleEndian.PutUint64(blk[:], t)
+	var p polyval
+	p.update(&x.hkey, blk[:])
+	for ; len(tweak) >= BlockSize; tweak = tweak[BlockSize:] {
+		p.update(&x.hkey, tweak)
+	}
+	if len(tweak) > 0 {
+		blk = [BlockSize]byte{}
+		copy(blk[:], tweak)
+		p.update(&x.hkey, blk[:])
+	}
+	return p
+}
+
+// hashMessage continua o hash com a mensagem; um bloco final incompleto
+// recebe o preenchimento 10*.
+func (x *HCTR2) hashMessage(p polyval, m []byte) [BlockSize]byte {
+	for ; len(m) >= BlockSize; m = m[BlockSize:] {
+		p.update(&x.hkey, m)
+	}
+	if len(m) > 0 {
+		var blk [BlockSize]byte
+		copy(blk[:], m)
+		blk[len(m)] = 1
+		p.update(&x.hkey, blk[:])
+	}
+	return p.sum()
+}
+
+// --- POLYVAL (RFC 8452) ---
+
+// POLYVAL é calculado pelo GHASH, conforme o apêndice A do RFC 8452:
+// POLYVAL(H, X) = rev(GHASH(mulX(rev(H)), rev(X))), onde rev inverte a
+// ordem dos bytes. Os elementos ficam na representação do GHASH, em duas
+// palavras big-endian.
+
+type polyvalKey struct{ hi, lo uint64 }
+
+type polyval struct{ hi, lo uint64 }
+
+// ghashR é o polinômio de redução do GHASH na ordem de bits refletida.
+const ghashR = 0xE1 << 56
+
+func newPolyvalKey(h *[BlockSize]byte) polyvalKey {
+	// rev(H) lido em big-endian é H lido em little-endian.
+	k := polyvalKey{hi: binary.LittleEndian.Uint64(h[8:]), lo: binary.LittleEndian.Uint64(h[:8])}
+	// mulX: deslocamento de um bit na ordem refletida, com redução.
+	carry := k.lo & 1
+	k.lo = k.lo>>1 | k.hi<<63
+	k.hi = k.hi>>1 ^ ghashR&-carry
+	return k
+}
+
+// update absorve um bloco de 16 bytes: acc = (acc ⊕ rev(x)) · H.
+func (p *polyval) update(k *polyvalKey, x []byte) {
+	xhi := p.hi ^ binary.LittleEndian.Uint64(x[8:])
+	xlo := p.lo ^ binary.LittleEndian.Uint64(x[:8])
+	// Multiplicação bit a bit em tempo constante (SP 800-38D, algoritmo 1).
+	var zhi, zlo uint64
+	vhi, vlo := k.hi, k.lo
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = xhi >> (63 - i) & 1
+		} else {
+			bit = xlo >> (127 - i) & 1
+		}
+		zhi ^= vhi & -bit
+		zlo ^= vlo & -bit
+		carry := vlo & 1
+		vlo = vlo>>1 | vhi<<63
+		vhi = vhi>>1 ^ ghashR&-carry
+	}
+	p.hi, p.lo = zhi, zlo
+}
+
+func (p *polyval) sum() (out [BlockSize]byte) {
+	binary.LittleEndian.PutUint64(out[:8], p.lo)
+	binary.LittleEndian.PutUint64(out[8:], p.hi)
+	return out
+}
@@ -0,0 +1,109 @@
+// Package jose traz Ginga e GingaHash para JOSE, na serialização compacta:
+//
+//   - JWS com "alg": "HG256", HMAC-GingaHash (RFC 7515);
+//   - JWE com "enc": "G256OCB", Ginga-OCB3 com IV de 12 bytes e tag de 16,
+//     e "alg": "G256KW" (key wrap da RFC 3394 com Ginga) ou "dir" (RFC 7516).
+//
+// Os identificadores não são registrados na IANA; servem para experimentar
+// tokens com Ginga entre serviços que aceitem esses nomes. As claims não são
+// interpretadas: "exp", "nbf", "aud" e afins ficam a cargo de quem chama.
+package jose
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Identificadores de algoritmo.
+const (
+	HG256   = "HG256"   // JWS: HMAC-GingaHash
+	G256KW  = "G256KW"  // JWE alg: key wrap com Ginga e KEK de 32 bytes
+	Direct  = "dir"     // JWE alg: a chave compartilhada é a própria CEK
+	G256OCB = "G256OCB" // JWE enc: Ginga-OCB3 com CEK de 32 bytes
+)
+
+// Header é o cabeçalho protegido de um JWS ou JWE.
+type Header map[string]any
+
+// Alg devolve o parâmetro "alg", ou "" se ausente ou não for texto.
+func (h Header) Alg() string { return h.str("alg") }
+
+// Enc devolve o parâmetro "enc", ou "" se ausente ou não for texto.
+func (h Header) Enc() string { return h.str("enc") }
+
+func (h Header) str(name string) string {
+	s, _ := h[name].(string)
+	return s
+}
+
+var (
+	// ErrMalformed indica um token que não segue a serialização compacta.
+	ErrMalformed = errors.New("jose: malformed compact serialization")
+
+	// ErrUnsupportedAlgorithm indica "alg" ou "enc" diferente dos
+	// suportados por este pacote, inclusive "none".
+	ErrUnsupportedAlgorithm = errors.New("jose: unsupported algorithm")
+
+	// ErrVerification indica assinatura inválida ou falha ao decifrar; a
+	// causa não é revelada.
+	ErrVerification = errors.New("jose: verification failed")
+)
+
+var b64 = base64.RawURLEncoding.Strict()
+
+// splitCompact divide token em exatamente n partes separadas por ponto.
+func splitCompact(token string, n int) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != n {
+		return nil, ErrMalformed
+	}
+	return parts, nil
+}
+
+func decodePart(s string) ([]byte, error) {
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return b, nil
+}
+
+// encodeHeader junta os parâmetros de extra aos fixados pelo algoritmo;
+// um parâmetro fixado não pode vir com outro valor em extra.
+func encodeHeader(extra Header, fixed Header) (string, error) {
+	h := make(Header, len(extra)+len(fixed))
+	for k, v := range extra {
+		h[k] = v
+	}
+	for k, v := range fixed {
+		if old, ok := extra[k]; ok && old != v {
+			return "", errors.New("jose: header parameter " + k + " conflicts with the algorithm")
+		}
+		h[k] = v
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	return b64.EncodeToString(b), nil
+}
+
+// decodeHeader lê o cabeçalho protegido. Parâmetros em "crit" não são
+// entendidos por este pacote, então qualquer "crit" é rejeitado (RFC 7515,
+// seção 4.1.11).
+func decodeHeader(s string) (Header, error) {
+	b, err := decodePart(s)
+	if err != nil {
+		return nil, err
+	}
+	var h Header
+	if err := json.Unmarshal(b, &h); err != nil || h == nil {
+		return nil, ErrMalformed
+	}
+	if _, ok := h["crit"]; ok {
+		return nil, errors.New("jose: critical header parameters are not supported")
+	}
+	return h, nil
+}
@@ -0,0 +1,145 @@
+package jose
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"github.com/pedroalbanese/ginga"
+)
+
+// --- JWE (G256KW / dir com G256OCB) ---
+//
+// Compacto: cabeçalho . chave cifrada . IV . texto cifrado . tag. O dado
+// associado é o cabeçalho protegido em base64url, como na RFC 7516.
+
+const (
+	cekSize = 32
+	ivSize  = 12
+	tagSize = 16
+)
+
+var errKeySize = errors.New("jose: G256KW and dir keys must be 32 bytes")
+
+func newG256OCB(cek []byte) (cipher.AEAD, error) {
+	b, err := ginga.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return ginga.NewOCB(b, ivSize, tagSize)
+}
+
+// Encrypt devolve o JWE compacto de plaintext com "enc" G256OCB. Com alg
+// G256KW, key é a KEK e a CEK é aleatória; com Direct, key é a própria CEK.
+// header traz parâmetros extras; "alg" e "enc" são preenchidos por Encrypt.
+func Encrypt(plaintext []byte, alg string, key []byte, header Header) (string, error) {
+	if len(key) != cekSize {
+		return "", errKeySize
+	}
+	var cek, encryptedKey []byte
+	switch alg {
+	case G256KW:
+		cek = make([]byte, cekSize)
+		if _, err := rand.Read(cek); err != nil {
+			return "", err
+		}
+		kek, err := ginga.NewCipher(key)
+		if err != nil {
+			return "", err
+		}
+		if encryptedKey, err = ginga.WrapKey(kek, cek); err != nil {
+			return "", err
+		}
+	case Direct:
+		cek = key
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+
+	h, err := encodeHeader(header, Header{"alg": alg, "enc": G256OCB})
+	if err != nil {
+		return "", err
+	}
+	a, err := newG256OCB(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := a.Seal(nil, iv, plaintext, []byte(h))
+	ct, tag := sealed[:len(plaintext)], sealed[len(plaintext):]
+
+	return h + "." + b64.EncodeToString(encryptedKey) + "." + b64.EncodeToString(iv) + "." +
+		b64.EncodeToString(ct) + "." + b64.EncodeToString(tag), nil
+}
+
+// Decrypt abre um JWE compacto com "alg" G256KW ou dir e "enc" G256OCB, e
+// devolve o texto claro e o cabeçalho. Outros algoritmos e "zip" são
+// rejeitados antes de qualquer operação com a chave.
+func Decrypt(token string, key []byte) ([]byte, Header, error) {
+	if len(key) != cekSize {
+		return nil, nil, errKeySize
+	}
+	parts, err := splitCompact(token, 5)
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	alg := h.Alg()
+	if alg != G256KW && alg != Direct || h.Enc() != G256OCB {
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if _, ok := h["zip"]; ok {
+		return nil, nil, errors.New("jose: compressed payloads are not supported")
+	}
+
+	var raw [4][]byte
+	for i := range raw {
+		if raw[i], err = decodePart(parts[i+1]); err != nil {
+			return nil, nil, err
+		}
+	}
+	encryptedKey, iv, ct, tag := raw[0], raw[1], raw[2], raw[3]
+	if len(iv) != ivSize || len(tag) != tagSize {
+		return nil, nil, ErrMalformed
+	}
+
+	var cek []byte
+	switch alg {
+	case Direct:
+		if len(encryptedKey) != 0 {
+			return nil, nil, ErrMalformed
+		}
+		cek = key
+	case G256KW:
+		kek, err := ginga.NewCipher(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		cek, err = ginga.UnwrapKey(kek, encryptedKey)
+		if err != nil || len(cek) != cekSize {
+			// Segue com uma CEK aleatória, para que uma chave mal
+			// embrulhada e uma tag inválida falhem do mesmo jeito (RFC
+			// 7516, seção 11.5).
+			cek = make([]byte, cekSize)
+			if _, err := rand.Read(cek); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	a, err := newG256OCB(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	pt, err := a.Open(nil, iv, append(ct, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, ErrVerification
+	}
+	return pt, h, nil
+}
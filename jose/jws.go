@@ -0,0 +1,76 @@
+package jose
+
+import (
+	"crypto/hmac"
+	"errors"
+
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// --- JWS (HG256) ---
+
+// MinMACKeySize é o menor tamanho de chave aceito por HG256: como em HS256,
+// ao menos o tamanho do digest.
+const MinMACKeySize = gingahash.DigestSize
+
+var errShortMACKey = errors.New("jose: HG256 key must be at least 32 bytes")
+
+func signingInput(header, payload string) []byte {
+	return []byte(header + "." + payload)
+}
+
+func macHG256(key, input []byte) []byte {
+	m := hmac.New(gingahash.New, key)
+	m.Write(input)
+	return m.Sum(nil)
+}
+
+// Sign devolve o JWS compacto de payload com HG256. header traz parâmetros
+// extras, como "typ" ou "kid"; "alg" é preenchido por Sign.
+func Sign(payload, key []byte, header Header) (string, error) {
+	if len(key) < MinMACKeySize {
+		return "", errShortMACKey
+	}
+	h, err := encodeHeader(header, Header{"alg": HG256})
+	if err != nil {
+		return "", err
+	}
+	p := b64.EncodeToString(payload)
+	sig := macHG256(key, signingInput(h, p))
+	return h + "." + p + "." + b64.EncodeToString(sig), nil
+}
+
+// Verify confere um JWS compacto assinado com HG256 e devolve o payload e o
+// cabeçalho. Qualquer outro "alg", inclusive "none", é rejeitado antes de
+// conferir a assinatura.
+func Verify(token string, key []byte) ([]byte, Header, error) {
+	if len(key) < MinMACKeySize {
+		return nil, nil, errShortMACKey
+	}
+	parts, err := splitCompact(token, 3)
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if h.Alg() != HG256 {
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if _, ok := h["enc"]; ok {
+		return nil, nil, ErrMalformed
+	}
+	payload, err := decodePart(parts[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := decodePart(parts[2])
+	if err != nil {
+		return nil, nil, err
+	}
+	if !hmac.Equal(sig, macHG256(key, signingInput(parts[0], parts[1]))) {
+		return nil, nil, ErrVerification
+	}
+	return payload, h, nil
+}
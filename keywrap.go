@@ -0,0 +1,174 @@
+package ginga
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// --- Key wrap (RFC 3394 / RFC 5649) ---
+
+// IntegrityError é devolvido por UnwrapKey e UnwrapKeyWithPadding quando o
+// valor de verificação não confere: chave de embrulho errada ou dado
+// adulterado. Nenhum byte da chave é devolvido nesse caso.
+type IntegrityError struct {
+	Mode string // "KW" ou "KWP"
+}
+
+func (e *IntegrityError) Error() string {
+	return "ginga: " + e.Mode + " integrity check failed"
+}
+
+// kwIV é o valor inicial padrão do RFC 3394; kwpIV é o prefixo do valor
+// inicial alternativo do RFC 5649, seguido do tamanho da chave.
+const (
+	kwIV  = 0xA6A6A6A6A6A6A6A6
+	kwpIV = 0xA65959A6
+)
+
+// wrap é o processo W do RFC 3394: seis passadas sobre os semiblocos de r,
+// que é sobrescrito.
+func wrap(b cipher.Block, a uint64, r []byte) uint64 {
+	var buf [BlockSize]byte
+	n := len(r) / 8
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			binary.BigEndian.PutUint64(buf[:8], a)
+			copy(buf[8:], r[8*i:8*i+8])
+			b.Encrypt(buf[:], buf[:])
+			a = binary.BigEndian.Uint64(buf[:8]) ^ uint64(n*j+i+1)
+			copy(r[8*i:], buf[8:])
+		}
+	}
+	return a
+}
+
+// unwrap é o processo inverso W⁻¹ e devolve o valor de verificação.
+func unwrap(b cipher.Block, a uint64, r []byte) uint64 {
+	var buf [BlockSize]byte
+	n := len(r) / 8
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			binary.BigEndian.PutUint64(buf[:8], a^uint64(n*j+i+1))
+			copy(buf[8:], r[8*i:8*i+8])
+			b.Decrypt(buf[:], buf[:])
+			a = binary.BigEndian.Uint64(buf[:8])
+			copy(r[8*i:], buf[8:])
+		}
+	}
+	return a
+}
+
+func checkKEK(b cipher.Block) error {
+	if b.BlockSize() != BlockSize {
+		return errors.New("ginga: key wrap requires a 16-byte block cipher")
+	}
+	return nil
+}
+
+// WrapKey embrulha key (RFC 3394) com a cifra b, normalmente uma chave
+// mestra Ginga. key deve ter ao menos 16 bytes e tamanho múltiplo de 8; o
+// resultado tem 8 bytes a mais. Para outros tamanhos, use
+// WrapKeyWithPadding.
+func WrapKey(b cipher.Block, key []byte) ([]byte, error) {
+	if err := checkKEK(b); err != nil {
+		return nil, err
+	}
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, errors.New("ginga: KW key must be a multiple of 8 bytes, at least 16")
+	}
+	out := make([]byte, len(key)+8)
+	copy(out[8:], key)
+	binary.BigEndian.PutUint64(out, wrap(b, kwIV, out[8:]))
+	return out, nil
+}
+
+// UnwrapKey desfaz WrapKey. Devolve *IntegrityError se o valor de
+// verificação não confere.
+func UnwrapKey(b cipher.Block, wrapped []byte) ([]byte, error) {
+	if err := checkKEK(b); err != nil {
+		return nil, err
+	}
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("ginga: KW wrapped key must be a multiple of 8 bytes, at least 24")
+	}
+	key := append([]byte(nil), wrapped[8:]...)
+	a := unwrap(b, binary.BigEndian.Uint64(wrapped), key)
+	var got, want [8]byte
+	binary.BigEndian.PutUint64(got[:], a)
+	binary.BigEndian.PutUint64(want[:], kwIV)
+	if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+		clear(key)
+		return nil, &IntegrityError{Mode: "KW"}
+	}
+	return key, nil
+}
+
+// WrapKeyWithPadding embrulha uma chave de qualquer tamanho entre 1 byte e
+// 2^32-1 bytes (RFC 5649). O tamanho original vai no valor de verificação e
+// o resultado ocupa o tamanho da chave arredondado para cima a 8 bytes, mais
+// 8.
+func WrapKeyWithPadding(b cipher.Block, key []byte) ([]byte, error) {
+	if err := checkKEK(b); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 || uint64(len(key)) > 1<<32-1 {
+		return nil, errors.New("ginga: KWP key must be between 1 and 2^32-1 bytes")
+	}
+	padded := (len(key) + 7) &^ 7
+	out := make([]byte, padded+8)
+	aiv := uint64(kwpIV)<<32 | uint64(len(key))
+	copy(out[8:], key)
+	if padded == 8 {
+		// Um único semibloco: basta cifrar AIV || P.
+		binary.BigEndian.PutUint64(out, aiv)
+		b.Encrypt(out, out)
+		return out, nil
+	}
+	binary.BigEndian.PutUint64(out, wrap(b, aiv, out[8:]))
+	return out, nil
+}
+
+// UnwrapKeyWithPadding desfaz WrapKeyWithPadding. Devolve *IntegrityError se
+// o valor de verificação, o tamanho declarado ou o preenchimento não
+// conferem.
+func UnwrapKeyWithPadding(b cipher.Block, wrapped []byte) ([]byte, error) {
+	if err := checkKEK(b); err != nil {
+		return nil, err
+	}
+	if len(wrapped) < 16 || len(wrapped)%8 != 0 {
+		return nil, errors.New("ginga: KWP wrapped key must be a multiple of 8 bytes, at least 16")
+	}
+	var a uint64
+	key := make([]byte, len(wrapped)-8)
+	if len(wrapped) == 16 {
+		var buf [BlockSize]byte
+		b.Decrypt(buf[:], wrapped)
+		a = binary.BigEndian.Uint64(buf[:8])
+		copy(key, buf[8:])
+	} else {
+		copy(key, wrapped[8:])
+		a = unwrap(b, binary.BigEndian.Uint64(wrapped), key)
+	}
+
+	// Confere prefixo, tamanho e preenchimento sem desvios que dependam de
+	// qual deles falhou. As comparações de subtle valem para inteiros de 31
+	// bits; um tamanho com o bit alto ligado já é inválido.
+	mli := int(uint32(a) & 0x7FFFFFFF)
+	ok := subtle.ConstantTimeEq(int32(uint32(a>>32)^kwpIV), 0)
+	ok &= subtle.ConstantTimeEq(int32(uint32(a)>>31), 0)
+	ok &= subtle.ConstantTimeLessOrEq(len(key)-7, mli)
+	ok &= subtle.ConstantTimeLessOrEq(mli, len(key))
+	var pad byte
+	for i := len(key) - 7; i < len(key); i++ {
+		// Bytes a partir de mli devem ser zero.
+		pad |= key[i] & byte(subtle.ConstantTimeLessOrEq(mli, i)*0xFF)
+	}
+	ok &= subtle.ConstantTimeByteEq(pad, 0)
+	if ok != 1 {
+		clear(key)
+		return nil, &IntegrityError{Mode: "KWP"}
+	}
+	return key[:mli], nil
+}
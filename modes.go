@@ -0,0 +1,138 @@
+package ginga
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// batch é o número de blocos gerados por chamada a EncryptBlocks nos modos.
+const batch = 8
+
+// encryptBlocks usa o caminho de vários blocos quando a cifra o oferece.
+func encryptBlocks(b cipher.Block, dst, src []byte) {
+	if mb, ok := b.(multiBlock); ok {
+		mb.EncryptBlocks(dst, src)
+		return
+	}
+	bs := b.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		b.Encrypt(dst[i:i+bs], src[i:i+bs])
+	}
+}
+
+func decryptBlocks(b cipher.Block, dst, src []byte) {
+	if mb, ok := b.(multiBlock); ok {
+		mb.DecryptBlocks(dst, src)
+		return
+	}
+	bs := b.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		b.Decrypt(dst[i:i+bs], src[i:i+bs])
+	}
+}
+
+// --- ECB ---
+
+type ecb struct {
+	b       cipher.Block
+	decrypt bool
+}
+
+// NewECBEncrypter devolve um cipher.BlockMode que cifra cada bloco
+// isoladamente. ECB vaza blocos repetidos; serve de base para outros modos
+// e para medir desempenho.
+func NewECBEncrypter(b cipher.Block) cipher.BlockMode {
+	return &ecb{b: b}
+}
+
+// NewECBDecrypter devolve o cipher.BlockMode inverso de NewECBEncrypter.
+func NewECBDecrypter(b cipher.Block) cipher.BlockMode {
+	return &ecb{b: b, decrypt: true}
+}
+
+func (e *ecb) BlockSize() int { return e.b.BlockSize() }
+
+func (e *ecb) CryptBlocks(dst, src []byte) {
+	if len(src)%e.b.BlockSize() != 0 {
+		panic("ginga: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("ginga: output smaller than input")
+	}
+	if e.decrypt {
+		decryptBlocks(e.b, dst[:len(src)], src)
+	} else {
+		encryptBlocks(e.b, dst[:len(src)], src)
+	}
+}
+
+// --- XTS ---
+
+// XTS implementa o modo XTS (IEEE 1619) para cifra de setores: a chave de 64
+// bytes é dividida em uma chave de dados e uma chave de ajuste (tweak).
+type XTS struct {
+	k1, k2 *gingaCipher
+}
+
+// NewXTS cria um XTS a partir de uma chave de 64 bytes.
+func NewXTS(key []byte) (*XTS, error) {
+	if len(key) != 64 {
+		return nil, errors.New("ginga: XTS key must be 64 bytes")
+	}
+	return &XTS{
+		k1: &gingaCipher{key: append([]byte(nil), key[:32]...), sk: schedule(key[:32])},
+		k2: &gingaCipher{key: append([]byte(nil), key[32:]...), sk: schedule(key[32:])},
+	}, nil
+}
+
+// mulAlpha multiplica o ajuste por α em GF(2^128), na ordem little-endian do
+// IEEE 1619.
+func mulAlpha(t *[BlockSize]byte) {
+	carry := t[BlockSize-1] >> 7
+	for i := BlockSize - 1; i > 0; i-- {
+		t[i] = t[i]<<1 | t[i-1]>>7
+	}
+	t[0] = t[0]<<1 ^ 0x87*carry
+}
+
+// Encrypt cifra um setor inteiro; len(src) deve ser múltiplo de 16.
+func (x *XTS) Encrypt(dst, src []byte, sector uint64) {
+	x.crypt(dst, src, sector, false)
+}
+
+// Decrypt decifra um setor cifrado por Encrypt com o mesmo número.
+func (x *XTS) Decrypt(dst, src []byte, sector uint64) {
+	x.crypt(dst, src, sector, true)
+}
+
+func (x *XTS) crypt(dst, src []byte, sector uint64, decrypt bool) {
+	if len(src) == 0 || len(src)%BlockSize != 0 {
+		panic("ginga: XTS sector must be a positive multiple of 16 bytes")
+	}
+	if len(dst) < len(src) {
+		panic("ginga: output smaller than input")
+	}
+
+	var t [BlockSize]byte
+	binary.LittleEndian.PutUint64(t[:], sector)
+	encrypt1(t[:], t[:], &x.k2.sk)
+
+	var tweaks, buf [batch * BlockSize]byte
+	for len(src) > 0 {
+		n := min(len(src), len(buf))
+		for i := 0; i < n; i += BlockSize {
+			copy(tweaks[i:], t[:])
+			mulAlpha(&t)
+		}
+		subtle.XORBytes(buf[:n], src[:n], tweaks[:n])
+		if decrypt {
+			x.k1.DecryptBlocks(buf[:n], buf[:n])
+		} else {
+			x.k1.EncryptBlocks(buf[:n], buf[:n])
+		}
+		subtle.XORBytes(dst[:n], buf[:n], tweaks[:n])
+		src, dst = src[n:], dst[n:]
+	}
+}
@@ -0,0 +1,332 @@
+package noise
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// --- Padrões e HandshakeState ---
+
+type token int
+
+const (
+	tokenE token = iota
+	tokenS
+	tokenEE
+	tokenES
+	tokenSE
+	tokenSS
+)
+
+// Pattern é um padrão de handshake: as chaves estáticas já conhecidas antes
+// do handshake e os tokens de cada mensagem, alternando iniciador e
+// respondedor a partir do iniciador.
+type Pattern struct {
+	Name string
+	// PreResponderS indica que o iniciador conhece a chave estática do
+	// respondedor de antemão (<- s).
+	PreResponderS bool
+	messages      [][]token
+}
+
+var (
+	// PatternXX: as duas chaves estáticas são transmitidas cifradas.
+	//
+	//	-> e
+	//	<- e, ee, s, es
+	//	-> s, se
+	PatternXX = Pattern{Name: "XX", messages: [][]token{
+		{tokenE},
+		{tokenE, tokenEE, tokenS, tokenES},
+		{tokenS, tokenSE},
+	}}
+
+	// PatternIK: o iniciador já conhece a chave estática do respondedor e
+	// envia a sua na primeira mensagem.
+	//
+	//	<- s
+	//	...
+	//	-> e, es, s, ss
+	//	<- e, ee, se
+	PatternIK = Pattern{Name: "IK", PreResponderS: true, messages: [][]token{
+		{tokenE, tokenES, tokenS, tokenSS},
+		{tokenE, tokenEE, tokenSE},
+	}}
+)
+
+const dhLen = 32
+
+// MaxMessageLen é o tamanho máximo de uma mensagem Noise (seção 3).
+const MaxMessageLen = 65535
+
+var (
+	errHandshakeDone = errors.New("noise: handshake already finished")
+	errWrongTurn     = errors.New("noise: not this party's turn")
+	errShortMessage  = errors.New("noise: message too short")
+	errMissingKey    = errors.New("noise: missing static key")
+	errMessageSize   = errors.New("noise: message exceeds 65535 bytes")
+)
+
+// Config configura um HandshakeState.
+type Config struct {
+	Pattern   Pattern
+	Initiator bool
+	Prologue  []byte
+	// StaticKey é a chave estática local (obrigatória em XX e IK).
+	StaticKey *ecdh.PrivateKey
+	// PeerStatic é a chave estática do respondedor, conhecida de antemão
+	// pelo iniciador em IK.
+	PeerStatic *ecdh.PublicKey
+	// Ephemeral fixa a chave efêmera, só para vetores de teste; nil gera
+	// uma nova.
+	Ephemeral *ecdh.PrivateKey
+	// Random é a fonte das chaves efêmeras; nil usa crypto/rand.
+	Random io.Reader
+
+	CipherFunc CipherFunc // nil usa CipherGinga
+	HashFunc   HashFunc   // nil usa HashGinga
+}
+
+// HandshakeState executa um handshake Noise (seção 5.3) com DH 25519.
+type HandshakeState struct {
+	ss        *symmetricState
+	s, e      *ecdh.PrivateKey
+	rs, re    *ecdh.PublicKey
+	initiator bool
+	random    io.Reader
+	messages  [][]token
+	msg       int // próxima mensagem do padrão
+}
+
+// NewHandshakeState inicia um handshake: nome do protocolo, prólogo e chaves
+// pré-conhecidas entram no hash.
+func NewHandshakeState(c Config) (*HandshakeState, error) {
+	cf, hf := c.CipherFunc, c.HashFunc
+	if cf == nil {
+		cf = CipherGinga
+	}
+	if hf == nil {
+		hf = HashGinga
+	}
+	if hf.HashLen() != 32 && hf.HashLen() != 64 || hf.BlockLen() < hf.HashLen() {
+		return nil, errors.New("noise: hash function must have HASHLEN 32 or 64 and BLOCKLEN ≥ HASHLEN")
+	}
+	if c.StaticKey == nil {
+		return nil, errMissingKey
+	}
+	if c.Pattern.PreResponderS && c.Initiator && c.PeerStatic == nil {
+		return nil, errors.New("noise: pattern needs the responder's static key")
+	}
+
+	name := "Noise_" + c.Pattern.Name + "_25519_" + cf.CipherName() + "_" + hf.HashName()
+	h := &HandshakeState{
+		ss:        newSymmetricState(cf, hf, name),
+		s:         c.StaticKey,
+		e:         c.Ephemeral,
+		initiator: c.Initiator,
+		random:    c.Random,
+		messages:  c.Pattern.messages,
+	}
+	if h.random == nil {
+		h.random = rand.Reader
+	}
+	h.ss.mixHash(c.Prologue)
+	if c.Pattern.PreResponderS {
+		if c.Initiator {
+			h.rs = c.PeerStatic
+			h.ss.mixHash(h.rs.Bytes())
+		} else {
+			h.ss.mixHash(h.s.PublicKey().Bytes())
+		}
+	}
+	return h, nil
+}
+
+// HandshakeHash devolve h, que identifica a sessão e serve para vincular o
+// canal (channel binding) depois do handshake.
+func (h *HandshakeState) HandshakeHash() []byte {
+	return append([]byte(nil), h.ss.h...)
+}
+
+// PeerStatic devolve a chave estática do par, quando já conhecida.
+func (h *HandshakeState) PeerStatic() *ecdh.PublicKey { return h.rs }
+
+func (h *HandshakeState) myTurn() bool {
+	return (h.msg%2 == 0) == h.initiator
+}
+
+// dh calcula DH(local, remoto) e o mistura na chave.
+func (h *HandshakeState) dh(local *ecdh.PrivateKey, remote *ecdh.PublicKey) error {
+	if local == nil || remote == nil {
+		return errMissingKey
+	}
+	z, err := local.ECDH(remote)
+	if err != nil {
+		return err
+	}
+	h.ss.mixKey(z)
+	return nil
+}
+
+// dhToken resolve ee, es, se e ss conforme o papel: em "es", a efêmera é do
+// iniciador e a estática do respondedor.
+func (h *HandshakeState) dhToken(t token) error {
+	switch t {
+	case tokenEE:
+		return h.dh(h.e, h.re)
+	case tokenSS:
+		return h.dh(h.s, h.rs)
+	case tokenES:
+		if h.initiator {
+			return h.dh(h.e, h.rs)
+		}
+		return h.dh(h.s, h.re)
+	default: // tokenSE
+		if h.initiator {
+			return h.dh(h.s, h.re)
+		}
+		return h.dh(h.e, h.rs)
+	}
+}
+
+// snapshot guarda o estado mutável do handshake e devolve a função que o
+// restaura, para que uma mensagem inválida não deixe efeito.
+func (h *HandshakeState) snapshot() (restore func()) {
+	saved := *h.ss
+	savedCS := *h.ss.cs
+	e, re, rs := h.e, h.re, h.rs
+	return func() {
+		*h.ss = saved
+		*h.ss.cs = savedCS
+		h.e, h.re, h.rs = e, re, rs
+	}
+}
+
+// WriteMessage acrescenta a out a próxima mensagem do handshake, com o
+// payload. Depois da última mensagem, devolve os CipherStates de envio e de
+// recepção deste lado; antes disso, ambos são nil. Uma mensagem maior que
+// MaxMessageLen é recusada sem alterar o estado.
+func (h *HandshakeState) WriteMessage(out, payload []byte) ([]byte, *CipherState, *CipherState, error) {
+	if h.msg >= len(h.messages) {
+		return nil, nil, nil, errHandshakeDone
+	}
+	if !h.myTurn() {
+		return nil, nil, nil, errWrongTurn
+	}
+	if len(payload) > MaxMessageLen {
+		return nil, nil, nil, errMessageSize
+	}
+	restore := h.snapshot()
+	start := len(out)
+	var err error
+	for _, t := range h.messages[h.msg] {
+		switch t {
+		case tokenE:
+			if h.e == nil {
+				if h.e, err = ecdh.X25519().GenerateKey(h.random); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+			pub := h.e.PublicKey().Bytes()
+			out = append(out, pub...)
+			h.ss.mixHash(pub)
+		case tokenS:
+			if out, err = h.ss.encryptAndHash(out, h.s.PublicKey().Bytes()); err != nil {
+				return nil, nil, nil, err
+			}
+		default:
+			if err = h.dhToken(t); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	if out, err = h.ss.encryptAndHash(out, payload); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(out)-start > MaxMessageLen {
+		restore()
+		return nil, nil, nil, errMessageSize
+	}
+	send, recv := h.advance()
+	return out, send, recv, nil
+}
+
+// ReadMessage processa a próxima mensagem do par e acrescenta o payload a
+// out; os CipherStates seguem a mesma regra de WriteMessage. Mensagens
+// maiores que MaxMessageLen são recusadas.
+func (h *HandshakeState) ReadMessage(out, message []byte) ([]byte, *CipherState, *CipherState, error) {
+	if h.msg >= len(h.messages) {
+		return nil, nil, nil, errHandshakeDone
+	}
+	if h.myTurn() {
+		return nil, nil, nil, errWrongTurn
+	}
+	if len(message) > MaxMessageLen {
+		return nil, nil, nil, errMessageSize
+	}
+	// O estado só muda se a mensagem inteira for válida.
+	restore := h.snapshot()
+	fail := func(err error) ([]byte, *CipherState, *CipherState, error) {
+		restore()
+		return nil, nil, nil, err
+	}
+
+	var err error
+	for _, t := range h.messages[h.msg] {
+		switch t {
+		case tokenE:
+			if len(message) < dhLen {
+				return fail(errShortMessage)
+			}
+			if h.re, err = ecdh.X25519().NewPublicKey(message[:dhLen]); err != nil {
+				return fail(err)
+			}
+			h.ss.mixHash(message[:dhLen])
+			message = message[dhLen:]
+		case tokenS:
+			n := dhLen
+			if h.ss.cs.HasKey() {
+				n += h.ss.cs.aead.Overhead()
+			}
+			if len(message) < n {
+				return fail(errShortMessage)
+			}
+			pub, err := h.ss.decryptAndHash(nil, message[:n])
+			if err != nil {
+				return fail(err)
+			}
+			if h.rs, err = ecdh.X25519().NewPublicKey(pub); err != nil {
+				return fail(err)
+			}
+			message = message[n:]
+		default:
+			if err = h.dhToken(t); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if out, err = h.ss.decryptAndHash(out, message); err != nil {
+		return fail(err)
+	}
+	send, recv := h.advance()
+	return out, send, recv, nil
+}
+
+// advance passa à mensagem seguinte e, no fim do padrão, divide a chave:
+// o primeiro CipherState é o do iniciador para o respondedor.
+func (h *HandshakeState) advance() (send, recv *CipherState) {
+	h.msg++
+	if h.msg < len(h.messages) {
+		return nil, nil
+	}
+	c1, c2 := h.ss.split()
+	if h.initiator {
+		return c1, c2
+	}
+	return c2, c1
+}
+
+// Finished informa se todas as mensagens do padrão já foram trocadas.
+func (h *HandshakeState) Finished() bool { return h.msg >= len(h.messages) }
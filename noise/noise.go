@@ -0,0 +1,247 @@
+// Package noise traz a cifra e o hash de Ginga para o Noise Protocol
+// Framework (revisão 34): CipherState com Ginga-OCB3, HashFunction com
+// GingaHash, HMAC e HKDF, e uma máquina de estados de handshake mínima para
+// os padrões XX e IK com DH 25519. O nome do protocolo fica, por exemplo,
+// Noise_XX_25519_GingaOCB_GingaHash.
+//
+// O pacote não enquadra as mensagens num transporte nem traz padrões com PSK
+// ou fallback; quem usa SetNonce responde por nunca repetir um contador com
+// a mesma chave.
+package noise
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+
+	"github.com/pedroalbanese/ginga"
+	gingahash "github.com/pedroalbanese/ginga/hash"
+)
+
+// --- Funções de cifra e de hash ---
+
+// CipherFunc é uma função de cifra do Noise: AEAD com chave de 32 bytes e
+// nonce de 64 bits.
+type CipherFunc interface {
+	// Cipher devolve o AEAD da chave k.
+	Cipher(k [32]byte) cipher.AEAD
+	// Nonce codifica o contador n no nonce do AEAD.
+	Nonce(n uint64) []byte
+	// CipherName é o nome usado no nome do protocolo.
+	CipherName() string
+}
+
+// HashFunc é uma função de hash do Noise. HashLen deve ser 32 ou 64 e
+// BlockLen ao menos HashLen, para o HMAC.
+type HashFunc interface {
+	Hash() hash.Hash
+	HashLen() int
+	BlockLen() int
+	HashName() string
+}
+
+type gingaOCB struct{}
+
+// CipherGinga é Ginga-OCB3 com tag de 16 bytes; o nonce é 0^32 seguido de n
+// em big-endian, como AESGCM no Noise.
+var CipherGinga CipherFunc = gingaOCB{}
+
+func (gingaOCB) Cipher(k [32]byte) cipher.AEAD {
+	b, err := ginga.NewCipher(k[:])
+	if err != nil {
+		panic(err)
+	}
+	a, err := ginga.NewOCB(b, 12, 16)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (gingaOCB) Nonce(n uint64) []byte {
+	var nonce [12]byte
+	binary.BigEndian.PutUint64(nonce[4:], n)
+	return nonce[:]
+}
+
+func (gingaOCB) CipherName() string { return "GingaOCB" }
+
+type gingaHashFunc struct{}
+
+// HashGinga é GingaHash: HASHLEN = 32 e BLOCKLEN = 32.
+var HashGinga HashFunc = gingaHashFunc{}
+
+func (gingaHashFunc) Hash() hash.Hash  { return gingahash.New() }
+func (gingaHashFunc) HashLen() int     { return gingahash.DigestSize }
+func (gingaHashFunc) BlockLen() int    { return gingahash.BlockSize }
+func (gingaHashFunc) HashName() string { return "GingaHash" }
+
+// hkdf é a HKDF do Noise (seção 4.3): devolve n saídas de HASHLEN bytes.
+func hkdf(h HashFunc, chainingKey, ikm []byte, n int) [][]byte {
+	mac := func(key, data []byte) []byte {
+		m := hmac.New(h.Hash, key)
+		m.Write(data)
+		return m.Sum(nil)
+	}
+	temp := mac(chainingKey, ikm)
+	out := make([][]byte, n)
+	prev := []byte(nil)
+	for i := 0; i < n; i++ {
+		prev = mac(temp, append(append([]byte(nil), prev...), byte(i+1)))
+		out[i] = prev
+	}
+	return out
+}
+
+// --- CipherState ---
+
+// ErrMaxNonce indica que o contador chegou a 2^64-1, valor reservado.
+var ErrMaxNonce = errors.New("noise: nonce exhausted")
+
+var errDecrypt = errors.New("noise: message authentication failed")
+
+// CipherState guarda a chave k e o contador n (seção 5.1). Sem chave,
+// EncryptWithAd e DecryptWithAd devolvem a entrada inalterada.
+type CipherState struct {
+	fn     CipherFunc
+	k      [32]byte
+	aead   cipher.AEAD
+	n      uint64
+	hasKey bool
+}
+
+// NewCipherState devolve um CipherState sem chave para a função fn.
+func NewCipherState(fn CipherFunc) *CipherState {
+	return &CipherState{fn: fn}
+}
+
+// InitializeKey define a chave e zera o contador.
+func (c *CipherState) InitializeKey(k [32]byte) {
+	c.k, c.aead, c.n, c.hasKey = k, c.fn.Cipher(k), 0, true
+}
+
+func (c *CipherState) HasKey() bool { return c.hasKey }
+
+// SetNonce define o contador, para protocolos que tratam mensagens fora de
+// ordem.
+func (c *CipherState) SetNonce(n uint64) { c.n = n }
+
+// Nonce devolve o contador atual.
+func (c *CipherState) Nonce() uint64 { return c.n }
+
+// EncryptWithAd cifra plaintext com dado associado ad e acrescenta o
+// resultado a out.
+func (c *CipherState) EncryptWithAd(out, ad, plaintext []byte) ([]byte, error) {
+	if !c.hasKey {
+		return append(out, plaintext...), nil
+	}
+	if c.n == math.MaxUint64 {
+		return nil, ErrMaxNonce
+	}
+	out = c.aead.Seal(out, c.fn.Nonce(c.n), plaintext, ad)
+	c.n++
+	return out, nil
+}
+
+// DecryptWithAd decifra ciphertext; numa falha de autenticação o contador
+// não avança.
+func (c *CipherState) DecryptWithAd(out, ad, ciphertext []byte) ([]byte, error) {
+	if !c.hasKey {
+		return append(out, ciphertext...), nil
+	}
+	if c.n == math.MaxUint64 {
+		return nil, ErrMaxNonce
+	}
+	out, err := c.aead.Open(out, c.fn.Nonce(c.n), ciphertext, ad)
+	if err != nil {
+		return nil, errDecrypt
+	}
+	c.n++
+	return out, nil
+}
+
+// Rekey troca a chave por ENCRYPT(k, 2^64-1, ε, 0^32), truncado a 32 bytes
+// (seção 4.2).
+func (c *CipherState) Rekey() {
+	var zeros [32]byte
+	out := c.aead.Seal(nil, c.fn.Nonce(math.MaxUint64), zeros[:], nil)
+	var k [32]byte
+	copy(k[:], out)
+	n := c.n
+	c.InitializeKey(k)
+	c.n = n
+}
+
+// --- SymmetricState ---
+
+type symmetricState struct {
+	cs   *CipherState
+	hash HashFunc
+	ck   []byte
+	h    []byte
+}
+
+func newSymmetricState(cf CipherFunc, hf HashFunc, protocolName string) *symmetricState {
+	s := &symmetricState{cs: NewCipherState(cf), hash: hf}
+	if len(protocolName) <= hf.HashLen() {
+		s.h = make([]byte, hf.HashLen())
+		copy(s.h, protocolName)
+	} else {
+		s.h = s.digest([]byte(protocolName))
+	}
+	s.ck = append([]byte(nil), s.h...)
+	return s
+}
+
+func (s *symmetricState) digest(parts ...[]byte) []byte {
+	h := s.hash.Hash()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func (s *symmetricState) mixKey(ikm []byte) {
+	out := hkdf(s.hash, s.ck, ikm, 2)
+	s.ck = out[0]
+	var k [32]byte
+	copy(k[:], out[1])
+	s.cs.InitializeKey(k)
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	s.h = s.digest(s.h, data)
+}
+
+func (s *symmetricState) encryptAndHash(out, plaintext []byte) ([]byte, error) {
+	start := len(out)
+	out, err := s.cs.EncryptWithAd(out, s.h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(out[start:])
+	return out, nil
+}
+
+func (s *symmetricState) decryptAndHash(out, ciphertext []byte) ([]byte, error) {
+	out, err := s.cs.DecryptWithAd(out, s.h, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(ciphertext)
+	return out, nil
+}
+
+func (s *symmetricState) split() (*CipherState, *CipherState) {
+	out := hkdf(s.hash, s.ck, nil, 2)
+	c1, c2 := NewCipherState(s.cs.fn), NewCipherState(s.cs.fn)
+	var k1, k2 [32]byte
+	copy(k1[:], out[0])
+	copy(k2[:], out[1])
+	c1.InitializeKey(k1)
+	c2.InitializeKey(k2)
+	return c1, c2
+}
@@ -0,0 +1,225 @@
+package ginga
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"math/bits"
+)
+
+// --- OCB3 (RFC 7253) ---
+
+var errOpen = errors.New("ginga: message authentication failed")
+
+// double multiplica por x em GF(2^128) na ordem big-endian (RFC 7253 e
+// CMAC), com polinômio x^128 + x^7 + x^2 + x + 1.
+func double(s [BlockSize]byte) [BlockSize]byte {
+	carry := s[0] >> 7
+	for i := 0; i < BlockSize-1; i++ {
+		s[i] = s[i]<<1 | s[i+1]>>7
+	}
+	s[BlockSize-1] = s[BlockSize-1]<<1 ^ 0x87*carry
+	return s
+}
+
+func xorBlock(dst *[BlockSize]byte, a, b []byte) {
+	subtle.XORBytes(dst[:], a[:BlockSize], b[:BlockSize])
+}
+
+// sliceForAppend estende in em n bytes e devolve também a parte nova.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return head, tail
+}
+
+type ocb struct {
+	b         cipher.Block
+	nonceSize int
+	tagSize   int
+	lStar     [BlockSize]byte
+	lDollar   [BlockSize]byte
+	l         [64][BlockSize]byte // L_i; ntz de um índice de bloco nunca passa de 63
+}
+
+// NewOCB devolve OCB3 (RFC 7253) sobre um bloco de 16 bytes, com nonce de 1 a
+// 15 bytes e tag de 1 a 16 bytes. OCB cifra e autentica em uma só passada.
+func NewOCB(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != BlockSize {
+		return nil, errors.New("ginga: OCB requires a 16-byte block cipher")
+	}
+	if nonceSize < 1 || nonceSize > 15 {
+		return nil, errors.New("ginga: OCB nonce size must be between 1 and 15 bytes")
+	}
+	if tagSize < 1 || tagSize > BlockSize {
+		return nil, errors.New("ginga: OCB tag size must be between 1 and 16 bytes")
+	}
+	o := &ocb{b: b, nonceSize: nonceSize, tagSize: tagSize}
+	b.Encrypt(o.lStar[:], o.lStar[:])
+	o.lDollar = double(o.lStar)
+	o.l[0] = double(o.lDollar)
+	for i := 1; i < len(o.l); i++ {
+		o.l[i] = double(o.l[i-1])
+	}
+	return o, nil
+}
+
+func (o *ocb) NonceSize() int { return o.nonceSize }
+func (o *ocb) Overhead() int  { return o.tagSize }
+
+// offset0 calcula Offset_0 a partir do nonce.
+func (o *ocb) offset0(nonce []byte) (off [BlockSize]byte) {
+	var n [BlockSize]byte
+	copy(n[BlockSize-len(nonce):], nonce)
+	n[BlockSize-1-len(nonce)] |= 1
+	n[0] |= byte(o.tagSize*8%128) << 1
+	bottom := int(n[BlockSize-1] & 63)
+
+	var stretch [BlockSize + 8]byte
+	n[BlockSize-1] &^= 63
+	o.b.Encrypt(stretch[:BlockSize], n[:])
+	for i := 0; i < 8; i++ {
+		stretch[BlockSize+i] = stretch[i] ^ stretch[i+1]
+	}
+	byteShift, bitShift := bottom/8, uint(bottom%8)
+	for i := range off {
+		off[i] = stretch[i+byteShift] << bitShift
+		if bitShift > 0 {
+			off[i] |= stretch[i+byteShift+1] >> (8 - bitShift)
+		}
+	}
+	return off
+}
+
+// hash é HASH(K, A) da RFC 7253.
+func (o *ocb) hash(a []byte) (sum [BlockSize]byte) {
+	var off, buf [BlockSize]byte
+	i := 1
+	for ; len(a) >= BlockSize; a, i = a[BlockSize:], i+1 {
+		xorBlock(&off, off[:], o.l[bits.TrailingZeros(uint(i))][:])
+		xorBlock(&buf, a, off[:])
+		o.b.Encrypt(buf[:], buf[:])
+		xorBlock(&sum, sum[:], buf[:])
+	}
+	if len(a) > 0 {
+		xorBlock(&off, off[:], o.lStar[:])
+		buf = [BlockSize]byte{}
+		copy(buf[:], a)
+		buf[len(a)] = 0x80
+		xorBlock(&buf, buf[:], off[:])
+		o.b.Encrypt(buf[:], buf[:])
+		xorBlock(&sum, sum[:], buf[:])
+	}
+	return sum
+}
+
+// crypt cifra ou decifra os blocos completos de src em lotes, atualizando o
+// deslocamento e a soma de verificação (sempre sobre o texto claro).
+func (o *ocb) crypt(dst, src []byte, off, checksum *[BlockSize]byte, decrypt bool) {
+	var offs, buf [batch * BlockSize]byte
+	i := 1
+	for len(src) >= BlockSize {
+		n := min(len(src), len(buf)) &^ (BlockSize - 1)
+		for j := 0; j < n; j, i = j+BlockSize, i+1 {
+			xorBlock(off, off[:], o.l[bits.TrailingZeros(uint(i))][:])
+			copy(offs[j:], off[:])
+		}
+		if !decrypt {
+			for j := 0; j < n; j += BlockSize {
+				xorBlock(checksum, checksum[:], src[j:])
+			}
+		}
+		subtle.XORBytes(buf[:n], src[:n], offs[:n])
+		if decrypt {
+			decryptBlocks(o.b, buf[:n], buf[:n])
+		} else {
+			encryptBlocks(o.b, buf[:n], buf[:n])
+		}
+		subtle.XORBytes(dst[:n], buf[:n], offs[:n])
+		if decrypt {
+			for j := 0; j < n; j += BlockSize {
+				xorBlock(checksum, checksum[:], dst[j:])
+			}
+		}
+		src, dst = src[n:], dst[n:]
+	}
+}
+
+// tag conclui o cálculo da tag; plainTail é o texto claro da parte final
+// parcial (menos de 16 bytes), que entra no checksum com preenchimento 10*.
+func (o *ocb) tag(off, checksum *[BlockSize]byte, plainTail []byte, ad []byte) [BlockSize]byte {
+	if len(plainTail) > 0 {
+		var pad [BlockSize]byte
+		copy(pad[:], plainTail)
+		pad[len(plainTail)] = 0x80
+		xorBlock(checksum, checksum[:], pad[:])
+	}
+	var t [BlockSize]byte
+	xorBlock(&t, checksum[:], off[:])
+	xorBlock(&t, t[:], o.lDollar[:])
+	o.b.Encrypt(t[:], t[:])
+	h := o.hash(ad)
+	xorBlock(&t, t[:], h[:])
+	return t
+}
+
+func (o *ocb) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != o.nonceSize {
+		panic("ginga: incorrect nonce length given to OCB")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+o.tagSize)
+
+	off := o.offset0(nonce)
+	var checksum [BlockSize]byte
+	full := len(plaintext) &^ (BlockSize - 1)
+	o.crypt(out, plaintext[:full], &off, &checksum, false)
+
+	tail := plaintext[full:]
+	if len(tail) > 0 {
+		xorBlock(&off, off[:], o.lStar[:])
+		var pad [BlockSize]byte
+		o.b.Encrypt(pad[:], off[:])
+		// O checksum usa o texto claro, lido antes de dst sobrescrevê-lo.
+		saved := append([]byte(nil), tail...)
+		subtle.XORBytes(out[full:], tail, pad[:])
+		tail = saved
+	}
+	t := o.tag(&off, &checksum, tail, additionalData)
+	copy(out[len(plaintext):], t[:o.tagSize])
+	return ret
+}
+
+func (o *ocb) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != o.nonceSize {
+		panic("ginga: incorrect nonce length given to OCB")
+	}
+	if len(ciphertext) < o.tagSize {
+		return nil, errOpen
+	}
+	tag := ciphertext[len(ciphertext)-o.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-o.tagSize]
+	ret, out := sliceForAppend(dst, len(ciphertext))
+
+	off := o.offset0(nonce)
+	var checksum [BlockSize]byte
+	full := len(ciphertext) &^ (BlockSize - 1)
+	o.crypt(out, ciphertext[:full], &off, &checksum, true)
+
+	if len(ciphertext) > full {
+		xorBlock(&off, off[:], o.lStar[:])
+		var pad [BlockSize]byte
+		o.b.Encrypt(pad[:], off[:])
+		subtle.XORBytes(out[full:], ciphertext[full:], pad[:])
+	}
+	t := o.tag(&off, &checksum, out[full:], additionalData)
+	if subtle.ConstantTimeCompare(t[:o.tagSize], tag) != 1 {
+		clear(out)
+		return nil, errOpen
+	}
+	return ret, nil
+}
@@ -0,0 +1,84 @@
+package ginga
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// --- Ginga com ajuste (tweak) ---
+
+// TweakSize é o tamanho do ajuste em bytes.
+const TweakSize = 16
+
+// tweakInterval é o número de rodadas entre duas injeções do ajuste.
+const tweakInterval = 4
+
+// TweakableBlock é Ginga com um ajuste (tweak) público de 128 bits, à moda
+// de Threefish e SKINNY: o ajuste não tem escalonamento próprio e é somado
+// por XOR às subchaves nas rodadas 0, 4, 8 e 12. Na injeção s (rodada 4s), a
+// subchave da palavra i recebe ROTL(t[(i+s) mod 4], 8s), onde t são as quatro
+// palavras little-endian do ajuste.
+//
+// Justificativa: cada palavra do ajuste entra quatro vezes, sempre em outra
+// posição do estado e com outra rotação, de modo que uma diferença no ajuste
+// não se cancela com ela mesma na injeção seguinte (o mesmo papel de
+// t2 = t0 ⊕ t1 em Threefish). Entre duas injeções há quatro rodadas
+// completas, e após a última restam outras quatro; a seção "Ajuste" de
+// go run ./cmd mede a avalanche do ajuste e as diferenças relacionadas por
+// ajuste em cada número de rodadas. Com o ajuste zero, TweakableBlock
+// coincide com NewCipher sob a mesma chave.
+//
+// O ajuste é escolhido pelo adversário, então a segurança depende de não
+// haver diferenciais de ajuste relacionado; como a cifra, esta variante é
+// experimental.
+type TweakableBlock struct {
+	sk [Rounds][4]uint32
+}
+
+// NewTweakable cria um TweakableBlock a partir de uma chave de 32 bytes.
+func NewTweakable(key []byte) (*TweakableBlock, error) {
+	if len(key) != 32 {
+		return nil, errors.New("ginga: invalid key size (must be 32 bytes)")
+	}
+	return &TweakableBlock{sk: schedule(key)}, nil
+}
+
+// BlockSize retorna o tamanho do bloco da cifra (16 bytes).
+func (t *TweakableBlock) BlockSize() int {
+	return BlockSize
+}
+
+// tweaked devolve as subchaves com o ajuste injetado.
+func (t *TweakableBlock) tweaked(tweak []byte) *[Rounds][4]uint32 {
+	if len(tweak) != TweakSize {
+		panic("ginga: tweak must be 16 bytes")
+	}
+	var tw [4]uint32
+	for i := range tw {
+		tw[i] = binary.LittleEndian.Uint32(tweak[4*i:])
+	}
+	sk := t.sk
+	for r := 0; r < Rounds; r += tweakInterval {
+		s := r / tweakInterval
+		for i := 0; i < 4; i++ {
+			sk[r][i] ^= rotl32(tw[(i+s)&3], 8*s)
+		}
+	}
+	return &sk
+}
+
+// Encrypt cifra um bloco de 16 bytes sob o ajuste tweak (16 bytes).
+func (t *TweakableBlock) Encrypt(dst, src, tweak []byte) {
+	if len(src) < BlockSize || len(dst) < BlockSize {
+		panic("ginga: input not full block")
+	}
+	encrypt1(dst, src, t.tweaked(tweak))
+}
+
+// Decrypt decifra um bloco cifrado por Encrypt com o mesmo ajuste.
+func (t *TweakableBlock) Decrypt(dst, src, tweak []byte) {
+	if len(src) < BlockSize || len(dst) < BlockSize {
+		panic("ginga: input not full block")
+	}
+	decrypt1(dst, src, t.tweaked(tweak))
+}